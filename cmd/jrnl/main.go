@@ -10,7 +10,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/caarlos0/env"
 	"github.com/cjsaylor/jrnl/commands"
 )
 
@@ -25,6 +24,17 @@ var availableCommands = map[string]string{
 	"list-tags": "List all tags used in journal entries.",
 	"find":      "Find journal entries.",
 	"tag":       "Append a tag or tags to journal entries.",
+	"untag":     "Remove a tag or tags from journal entries.",
+	"retag":     "Rename or merge a tag across all journal entries.",
+	"config":    "Show the effective configuration and where each setting came from.",
+	"journals":  "List the configured journals.",
+	"write":     "Append text to a journal entry without opening an editor.",
+	"status":    "Show the changes memorize will commit and the state of the remote.",
+	"history":   "List the commits that changed an entry.",
+	"diff":      "Show the changes to an entry between two revisions.",
+	"export":    "Export the journal as a static HTML site or a book.",
+	"import":    "Import entries from jrnl.sh, Day One or a directory of markdown files.",
+	"serve":     "Browse, search and edit the journal in a web browser.",
 }
 
 var version = "dev"
@@ -41,11 +51,6 @@ func LongestStringLength(strings []string) int {
 }
 
 func init() {
-	config = commands.Configuration{}
-	env.Parse(&config)
-	if config.JournalPath == "" {
-		config.JournalPath = os.Getenv("HOME") + "/journal.wiki"
-	}
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: jrnl [options...] [command]\n\n")
 		fmt.Fprintf(os.Stderr, "Commands:\n\n")
@@ -66,27 +71,68 @@ func init() {
 }
 
 func FromCommandName(name string) (commands.CommandRunner, error) {
+	store := commands.NewFileStore(config.JournalPath)
 	switch name {
 	case "open":
 		return commands.NewOpenCommand(
 			config,
+			store,
 			&commands.ExternalEditorImpl{}), nil
 	case "memorize":
-		return commands.NewMemorizeCommand(config), nil
+		return commands.NewMemorizeCommand(
+			config,
+			store,
+			&commands.GitCommandRunnerImpl{}), nil
 	case "sync":
 		return commands.NewSyncCommand(
 			config,
-			&commands.GitCommandRunnerImpl{}), nil
+			store,
+			&commands.GitCommandRunnerImpl{},
+			os.Stdout), nil
 	case "index":
-		return commands.NewIndexCommand(config), nil
+		return commands.NewIndexCommand(config, store), nil
 	case "image":
-		return commands.NewImageCommand(config), nil
+		return commands.NewImageCommand(config, store), nil
 	case "list-tags":
-		return commands.NewListTagsCommand(config), nil
+		return commands.NewListTagsCommand(config, store, os.Stdout), nil
 	case "find":
-		return commands.NewFindCommand(config, os.Stdout), nil
+		return commands.NewFindCommand(config, store, os.Stdout), nil
 	case "tag":
-		return commands.NewTagCommand(config), nil
+		return commands.NewTagCommand(config, store), nil
+	case "untag":
+		return commands.NewUntagCommand(config, store, os.Stdout), nil
+	case "retag":
+		return commands.NewRetagCommand(config, store, os.Stdout), nil
+	case "config":
+		return commands.NewConfigCommand(config, os.Stdout), nil
+	case "journals":
+		return commands.NewJournalsCommand(config, os.Stdout), nil
+	case "write":
+		return commands.NewWriteCommand(config, store, os.Stdin), nil
+	case "status":
+		return commands.NewStatusCommand(
+			config,
+			store,
+			&commands.GitCommandRunnerImpl{},
+			os.Stdout), nil
+	case "history":
+		return commands.NewHistoryCommand(
+			config,
+			store,
+			&commands.GitCommandRunnerImpl{},
+			os.Stdout), nil
+	case "diff":
+		return commands.NewDiffCommand(
+			config,
+			store,
+			&commands.GitCommandRunnerImpl{},
+			os.Stdout), nil
+	case "export":
+		return commands.NewExportCommand(config, store, os.Stdout), nil
+	case "import":
+		return commands.NewImportCommand(config, store, os.Stdout), nil
+	case "serve":
+		return commands.NewServeCommand(config, store, os.Stdout), nil
 	default:
 		return nil, errors.New("Command not found")
 	}
@@ -102,12 +148,27 @@ func ParseDate(input string) (time.Time, error) {
 func main() {
 	dateInput := flag.String("date", now.Format("2006-01-02"), "Specify the date of entry.")
 	versionRequested := flag.Bool("version", false, "Prints the current version.")
+	configPath := flag.String("config", "", "Path to the configuration file (default $XDG_CONFIG_HOME/jrnl/config.yaml).")
+	flagConfig := commands.Configuration{}
+	flag.StringVar(&flagConfig.JournalPath, "path", "", "Path to the journal, overriding $JOURNAL_PATH.")
+	flag.StringVar(&flagConfig.JournalEditor, "editor", "", "Editor to use, overriding $JRNL_EDITOR.")
+	flag.StringVar(&flagConfig.Journal, "j", "", "Name of the configured journal to use.")
 	flag.Parse()
 
 	if *versionRequested {
 		fmt.Println(version)
 		os.Exit(0)
 	}
+	var err error
+	if *configPath != "" {
+		config, err = commands.LoadConfiguration(*configPath, true, flagConfig)
+	} else {
+		config, err = commands.LoadConfiguration(commands.DefaultConfigurationPath(), false, flagConfig)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load configuration: %v\n", err)
+		os.Exit(1)
+	}
 	parsedDate, err := ParseDate(*dateInput)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Unable to parse date: %v. Must be in form of YYYY-mm-dd", *dateInput)
@@ -124,6 +185,7 @@ func main() {
 		command = commandArgs[0]
 		commandArgs = commandArgs[1:]
 	}
+	commandArgs = append(append([]string{}, config.CommandDefaults[command]...), commandArgs...)
 	cmd, err := FromCommandName(command)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -91,6 +91,17 @@ func TestFromCommandName(t *testing.T) {
 		{"list-tags", "*ListTagsCommand", false},
 		{"find", "*FindCommand", false},
 		{"tag", "*TagCommand", false},
+		{"untag", "*UntagCommand", false},
+		{"retag", "*RetagCommand", false},
+		{"config", "*ConfigCommand", false},
+		{"journals", "*JournalsCommand", false},
+		{"write", "*WriteCommand", false},
+		{"status", "*StatusCommand", false},
+		{"history", "*HistoryCommand", false},
+		{"diff", "*DiffCommand", false},
+		{"export", "*ExportCommand", false},
+		{"import", "*ImportCommand", false},
+		{"serve", "*ServeCommand", false},
 		{"Unknown", "", true},
 	}
 
@@ -0,0 +1,255 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxAttachmentSize limits the size of an uploaded attachment.
+const maxAttachmentSize = 32 << 20
+
+// apiEntry is an entry in the JSON API: the record find outputs with its content.
+type apiEntry struct {
+	entryRecord
+	Content string `json:"content"`
+}
+
+// apiEntryUpdate is the body of a request changing an entry. Tags are only
+// changed when given.
+type apiEntryUpdate struct {
+	Content *string  `json:"content"`
+	Tags    []string `json:"tags"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// apiHandler serves the JSON API of the server.
+func (s *journalServer) apiHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/entries/", s.apiEntries)
+	mux.HandleFunc("/tags", s.apiTags)
+	mux.HandleFunc("/search", s.apiSearch)
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, apiError{Error: err.Error()})
+}
+
+func (s *journalServer) apiEntries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && !sameOrigin(r) {
+		writeJSONError(w, http.StatusForbidden, errCrossOrigin)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/entries/")
+	action := ""
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+		name, action = parts[0], parts[1]
+	}
+	switch {
+	case name == "":
+		http.NotFound(w, r)
+	case action == "" && r.Method == http.MethodGet:
+		s.apiGetEntry(w, name)
+	case action == "" && r.Method == http.MethodPut:
+		s.apiPutEntry(w, r, name)
+	case action == "tags" && r.Method == http.MethodPost:
+		s.apiTagEntry(w, r, name)
+	case action == "attachments" && r.Method == http.MethodPost:
+		s.apiAttach(w, r, name)
+	case action == "" || action == "tags" || action == "attachments":
+		writeJSONError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// readEntry reads an entry, writing the error response when it can not be used.
+func (s *journalServer) readEntry(w http.ResponseWriter, name string) (*entryHeader, bool) {
+	entry, err := s.journal.read(name)
+	switch {
+	case os.IsNotExist(err):
+		writeJSONError(w, http.StatusNotFound, err)
+	case err != nil:
+		writeJSONError(w, http.StatusInternalServerError, err)
+	case entry.locked:
+		writeJSONError(w, http.StatusForbidden, errMissingKey)
+	default:
+		return entry, true
+	}
+	return nil, false
+}
+
+func (s *journalServer) apiGetEntry(w http.ResponseWriter, name string) {
+	if entry, ok := s.readEntry(w, name); ok {
+		writeJSON(w, http.StatusOK, apiEntry{newEntryRecord(entry, nil), entry.Content})
+	}
+}
+
+// apiPutEntry replaces the content and tags of an entry, creating the entry
+// like open does when it does not exist.
+func (s *journalServer) apiPutEntry(w http.ResponseWriter, r *http.Request, name string) {
+	var update apiEntryUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	status := http.StatusOK
+	entry, err := s.journal.read(name)
+	if os.IsNotExist(err) {
+		status = http.StatusCreated
+		entry, err = s.newEntry(r.Context(), name)
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := s.journal.writable(entry); err != nil {
+		writeJSONError(w, http.StatusForbidden, err)
+		return
+	}
+	if update.Content != nil {
+		entry.Content = *update.Content
+	}
+	if update.Tags != nil {
+		tags := dedupe(update.Tags)
+		sort.Strings(tags)
+		entry.SetTags(tags)
+	}
+	if err := s.journal.write(entry); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, status, apiEntry{newEntryRecord(entry, nil), entry.Content})
+}
+
+// apiTagEntry appends tags to an entry like tag does.
+func (s *journalServer) apiTagEntry(w http.ResponseWriter, r *http.Request, name string) {
+	var update apiEntryUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.readEntry(w, name)
+	if !ok {
+		return
+	}
+	entryTags := dedupe(append(entry.Tags(), update.Tags...))
+	sort.Strings(entryTags)
+	entry.SetTags(entryTags)
+	if err := s.journal.write(entry); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, apiEntry{newEntryRecord(entry, nil), entry.Content})
+}
+
+// apiAttach appends the image uploaded in the file field of a multipart form
+// to an entry like image does.
+func (s *journalServer) apiAttach(w http.ResponseWriter, r *http.Request, name string) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	filename := path.Base(strings.Replace(header.Filename, "\\", "/", -1))
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := attachImage(s.journal, name, filename, data); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.apiGetEntry(w, name)
+}
+
+// apiTags lists the tags like list-tags -format json does, sorted by the
+// sort parameter.
+func (s *journalServer) apiTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		return
+	}
+	sortBy := r.FormValue("sort")
+	if sortBy == "" {
+		sortBy = "name"
+	}
+	entries, err := s.journal.entries()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	now := s.now()
+	usages := tagUsages(entries, dateRange{}, s.options.filenameDateFormat(), now.Location())
+	if err := sortTagUsages(usages, sortBy); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	records := make([]tagRecord, len(usages))
+	for i, usage := range usages {
+		records[i] = usage.record()
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+// apiSearch finds entries like find -format json does, by the q, where and
+// tag parameters.
+func (s *journalServer) apiSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+		return
+	}
+	r.ParseForm()
+	entries, matches, err := s.queryEntries(r.Form.Get("q"), r.Form.Get("where"), r.Form["tag"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	records := make([]entryRecord, len(entries))
+	for i, entry := range entries {
+		records[i] = newEntryRecord(entry, matches[i])
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+// newEntry creates an entry like open does. Entries named after a date are
+// dated that day, others are subjects dated now.
+func (s *journalServer) newEntry(ctx context.Context, name string) (*entryHeader, error) {
+	now := s.now()
+	ctx = context.WithValue(ctx, CommandContextKey("date"), now)
+	if name == s.options.entryName(now) {
+		return generateEntry(ctx, s.options, s.journal, "", "")
+	}
+	if date, err := time.ParseInLocation(s.options.filenameDateFormat(), name, now.Location()); err == nil {
+		ctx = context.WithValue(ctx, CommandContextKey("date"), date)
+		return generateEntry(ctx, s.options, s.journal, "", "")
+	}
+	return generateEntry(ctx, s.options, s.journal, name, "")
+}
@@ -0,0 +1,164 @@
+package commands_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func TestAPI(t *testing.T) {
+	store := fixtureStore(t)
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 9, 30, 0, 0, time.UTC))
+	handler, err := commands.NewServeCommand(commands.Configuration{}, store, os.Stdout).Handler(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	inputs := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		status   int
+		contains []string
+	}{
+		{"entry", http.MethodGet, "/api/entries/2018-08-03", "", http.StatusOK, []string{`"subject":"2018-08-03"`, `"tags":["incident","db","resolved"]`, `"content":"Raised the pool size and the incident is resolved."`}},
+		{"missing entry", http.MethodGet, "/api/entries/2017-01-01", "", http.StatusNotFound, []string{`"error":`}},
+		{"tags", http.MethodGet, "/api/tags?sort=count", "", http.StatusOK, []string{`{"tag":"incident","count":3,`}},
+		{"invalid tag sort", http.MethodGet, "/api/tags?sort=color", "", http.StatusBadRequest, []string{`"error":`}},
+		{"search", http.MethodGet, "/api/search?q=POOL", "", http.StatusOK, []string{`"filename":"2018-08-03.md"`, `"matches":[{"line":`}},
+		{"search tags", http.MethodGet, "/api/search?where=" + url.QueryEscape("incident and not db"), "", http.StatusOK, []string{`"filename":"2018-08-04.md"`}},
+		{"search error", http.MethodGet, "/api/search?where=" + url.QueryEscape("incident and"), "", http.StatusBadRequest, []string{`"error":`}},
+		{"create entry", http.MethodPut, "/api/entries/2018-08-07", `{"content":"Checked the pool.\n","tags":["db","followup"]}`, http.StatusCreated, []string{`"tags":["db","followup"]`, `"date":"2018-08-07T09:30:00Z"`, `"content":"Checked the pool.\n"`}},
+		{"update entry", http.MethodPut, "/api/entries/2018-08-07", `{"content":"Checked the pool again.\n"}`, http.StatusOK, []string{`"tags":["db","followup"]`, `"content":"Checked the pool again.\n"`}},
+		{"create subject", http.MethodPut, "/api/entries/Retro", `{"content":"Went well.\n"}`, http.StatusCreated, []string{`"subject":"Retro"`}},
+		{"invalid body", http.MethodPut, "/api/entries/2018-08-07", `content`, http.StatusBadRequest, []string{`"error":`}},
+		{"append tags", http.MethodPost, "/api/entries/2018-08-07/tags", `{"tags":["incident","db"]}`, http.StatusOK, []string{`"tags":["db","followup","incident"]`}},
+		{"tag missing entry", http.MethodPost, "/api/entries/2017-01-01/tags", `{"tags":["db"]}`, http.StatusNotFound, nil},
+		{"method not allowed", http.MethodDelete, "/api/entries/2018-08-07", "", http.StatusMethodNotAllowed, []string{`"error":"method not allowed"`}},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			request, _ := http.NewRequest(input.method, server.URL+input.path, strings.NewReader(input.body))
+			request.Header.Set("Content-Type", "application/json")
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+			body, _ := ioutil.ReadAll(response.Body)
+			if response.StatusCode != input.status {
+				t.Errorf("Expected status %d, got %d: %s", input.status, response.StatusCode, body)
+			}
+			if contentType := response.Header.Get("Content-Type"); input.contains != nil && contentType != "application/json" {
+				t.Errorf("Expected a JSON response, got %q", contentType)
+			}
+			for _, expected := range input.contains {
+				if !strings.Contains(string(body), expected) {
+					t.Errorf("Expected %q in %s", expected, body)
+				}
+			}
+		})
+	}
+	if content := readEntry(t, store, "2018-08-07"); !strings.HasSuffix(content, "- db\n- followup\n- incident\n---\nChecked the pool again.\n") {
+		t.Errorf("Expected the entry to be saved, got %q", content)
+	}
+
+	t.Run("attachment", func(t *testing.T) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		file, _ := form.CreateFormFile("file", "pixel.png")
+		file.Write([]byte("png"))
+		form.Close()
+		response, err := http.Post(server.URL+"/api/entries/2018-08-07/attachments", form.FormDataContentType(), &body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", response.StatusCode)
+		}
+		if image, err := store.Attachment("pixel.png"); err != nil || string(image) != "png" {
+			t.Errorf("Expected the image to be attached, got %q %v", image, err)
+		}
+		if content := readEntry(t, store, "2018-08-07"); !strings.HasSuffix(content, "![](bin/pixel.png)\n") {
+			t.Errorf("Expected the image to be appended, got %q", content)
+		}
+	})
+
+	t.Run("cross origin", func(t *testing.T) {
+		request, _ := http.NewRequest(http.MethodPut, server.URL+"/api/entries/2018-08-07", strings.NewReader(`{"content":"hacked"}`))
+		request.Header.Set("Origin", "https://example.com")
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected a forbidden request, got %d", response.StatusCode)
+		}
+	})
+}
+
+// slowStore returns entries slowly, so concurrent requests overlap.
+type slowStore struct {
+	*commands.MemoryStore
+}
+
+func (s slowStore) Read(name string) ([]byte, error) {
+	document, err := s.MemoryStore.Read(name)
+	time.Sleep(5 * time.Millisecond)
+	return document, err
+}
+
+func TestAPIConcurrentTags(t *testing.T) {
+	store := fixtureStore(t)
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 9, 30, 0, 0, time.UTC))
+	handler, err := commands.NewServeCommand(commands.Configuration{}, slowStore{store}, os.Stdout).Handler(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	var expected []string
+	for i := 0; i < 20; i++ {
+		tag := fmt.Sprintf("bot%02d", i)
+		expected = append(expected, "- "+tag+"\n")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := http.Post(server.URL+"/api/entries/2018-08-01/tags", "application/json", strings.NewReader(`{"tags":["`+tag+`"]}`))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			response.Body.Close()
+			if response.StatusCode != http.StatusOK {
+				t.Errorf("Expected status 200, got %d", response.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+	content := readEntry(t, store, "2018-08-01")
+	for _, tag := range expected {
+		if !strings.Contains(content, tag) {
+			t.Errorf("Expected %q to be kept, got %q", tag, content)
+		}
+	}
+}
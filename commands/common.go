@@ -2,13 +2,9 @@ package commands
 
 import (
 	"context"
-	"io/ioutil"
-	"os/exec"
-	"path"
-	"syscall"
-	"time"
-
-	"github.com/ericaro/frontmatter"
+	"fmt"
+	"sort"
+	"sync"
 )
 
 // CommandRunner is an interface for runnable commands
@@ -19,82 +15,134 @@ type CommandRunner interface {
 // CommandContextKey is a context key specific to commands package
 type CommandContextKey string
 
-func gitCommand(params ...string) int {
-	cmd := exec.Command("git", params...)
-	if err := cmd.Run(); err != nil {
-		if exiterr, ok := err.(*exec.ExitError); ok {
-			if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
-				return status.ExitStatus()
-			}
-		}
+// journal reads and writes the entries of a store, encrypting them as configured.
+type journal struct {
+	store  EntryStore
+	cipher *entryCipher
+}
+
+func openJournal(config Configuration, store EntryStore) (*journal, error) {
+	cipher, err := newEntryCipher(config)
+	if err != nil {
+		return nil, err
 	}
-	return 0
+	return &journal{store: store, cipher: cipher}, nil
 }
 
-const JournalTimeformat = "Mon Jan 2 2006 15:04:05 -0700 MST"
+type frontmatterResult struct {
+	header *entryHeader
+	err    error
+}
 
-type entryHeader struct {
-	Filepath string    `yaml:"-"`
-	Filename string    `yaml:"-"`
-	Tags     []string  `yaml:"tags,omitempty"`
-	Date     time.Time `yaml:"date,omitempty"`
-	Content  string    `fm:"content" yaml:"-"`
+// newEntry creates an empty entry with the name.
+func (j *journal) newEntry(name string) *entryHeader {
+	return &entryHeader{
+		Filepath: j.store.Path(name),
+		Filename: name + ".md",
+		name:     name,
+	}
 }
 
-func (e *entryHeader) MarshalFrontmatter() ([]byte, error) {
-	return frontmatter.Marshal(&struct {
-		Tags    []string `yaml:"tags,omitempty"`
-		Date    string   `yaml:"date"`
-		Content string   `fm:"content" yaml:"-"`
-	}{
-		Tags:    e.Tags,
-		Date:    e.Date.Format(JournalTimeformat),
-		Content: e.Content,
-	})
+// read reads an entry, returning an error for which os.IsNotExist is true
+// when the entry does not exist.
+func (j *journal) read(name string) (*entryHeader, error) {
+	content, err := j.store.Read(name)
+	if err != nil {
+		return nil, err
+	}
+	return j.parse(name, content)
 }
 
-func unmarshalFrontmatter(input []byte) (*entryHeader, error) {
-	type rawHeader struct {
-		Tags    []string `yaml:"tags,omitempty"`
-		Date    string   `yaml:"date,omitempty"`
-		Content string   `fm:"content" yaml:"-"`
+// parse reads an entry from its stored document.
+func (j *journal) parse(name string, content []byte) (*entryHeader, error) {
+	head, err := unmarshalFrontmatter(content)
+	if err == nil {
+		head, err = j.cipher.decrypt(head)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", j.store.Path(name), err)
 	}
-	raw := new(rawHeader)
-	frontmatter.Unmarshal(input, raw)
-	var date time.Time
-	var err error
-	if raw.Date != "" {
-		date, err = time.Parse(JournalTimeformat, raw.Date)
-		if err != nil {
-			return nil, err
+	head.Filepath = j.store.Path(name)
+	head.Filename = name + ".md"
+	head.name = name
+	return head, nil
+}
+
+// readAll reads the entries concurrently, returning them sorted by name.
+func (j *journal) readAll(names []string) ([]*entryHeader, error) {
+	var wg sync.WaitGroup
+	results := make(chan frontmatterResult, len(names))
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			head, err := j.read(name)
+			results <- frontmatterResult{
+				header: head,
+				err:    err,
+			}
+		}(name)
+	}
+	wg.Wait()
+	close(results)
+	entries := make([]*entryHeader, 0, len(names))
+	for result := range results {
+		if result.err != nil {
+			return nil, result.err
 		}
+		entries = append(entries, result.header)
 	}
-	return &entryHeader{
-		Tags:    raw.Tags,
-		Date:    date,
-		Content: raw.Content,
-	}, nil
+	sort.Slice(entries, func(i, k int) bool {
+		return entries[i].name < entries[k].name
+	})
+	return entries, nil
 }
 
-type frontmatterResult struct {
-	header *entryHeader
-	err    error
+// entries reads every entry of the journal.
+func (j *journal) entries() ([]*entryHeader, error) {
+	names, err := j.store.List()
+	if err != nil {
+		return nil, err
+	}
+	return j.readAll(names)
 }
 
-func readFrontmatter(filePath string, results chan<- frontmatterResult) {
-	content, err := ioutil.ReadFile(filePath)
+// writable reports why the entry can not be written back, if it can not.
+func (j *journal) writable(entry *entryHeader) error {
+	if entry.locked {
+		return fmt.Errorf("%s: %v", entry.Filepath, errMissingKey)
+	}
+	if j.cipher.shouldEncrypt(entry) && len(j.cipher.passphrase) == 0 {
+		return fmt.Errorf("%s: %v", entry.Filepath, errSealingKey)
+	}
+	return nil
+}
+
+// write stores the entry, encrypting it when required.
+func (j *journal) write(entry *entryHeader) error {
+	if err := j.writable(entry); err != nil {
+		return err
+	}
+	output, err := entry.MarshalFrontmatter()
 	if err != nil {
-		results <- frontmatterResult{
-			header: nil,
-			err:    err,
+		return err
+	}
+	if j.cipher.shouldEncrypt(entry) {
+		if output, err = j.cipher.encrypt(output); err != nil {
+			return err
 		}
-		return
 	}
-	head, err := unmarshalFrontmatter(content)
-	head.Filepath = filePath
-	head.Filename = path.Base(filePath)
-	results <- frontmatterResult{
-		header: head,
-		err:    err,
+	return j.store.Write(entry.name, output)
+}
+
+// update replaces an entry with an edited copy of its document, encrypting
+// it again if it was encrypted.
+func (j *journal) update(entry *entryHeader, edited []byte) error {
+	updated, err := unmarshalFrontmatter(edited)
+	if err != nil {
+		return err
 	}
+	updated.Filepath, updated.Filename, updated.name = entry.Filepath, entry.Filename, entry.name
+	updated.encrypted = entry.encrypted
+	return j.write(updated)
 }
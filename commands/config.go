@@ -1,7 +1,270 @@
 package commands
 
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/caarlos0/env"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	sourceDefault = "default"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+	sourceFlag    = "flag"
+)
+
+// Configuration is the effective configuration of jrnl, merged from defaults,
+// the configuration file, environment variables and flags.
 type Configuration struct {
-	JournalPath          string `env:"JOURNAL_PATH"`
-	JournalEditor        string `env:"JRNL_EDITOR" envDefault:"vim"`
-	JournalEditorOptions string `env:"JRNL_EDITOR_OPTIONS"`
+	JournalPath          string              `env:"JOURNAL_PATH" yaml:"journal_path"`
+	JournalEditor        string              `env:"JRNL_EDITOR" yaml:"editor"`
+	JournalEditorOptions string              `env:"JRNL_EDITOR_OPTIONS" yaml:"editor_options"`
+	FilenameDateFormat   string              `env:"JRNL_DATE_FORMAT" yaml:"filename_date_format"`
+	GitRemote            string              `env:"JRNL_GIT_REMOTE" yaml:"git_remote"`
+	GitBranch            string              `env:"JRNL_GIT_BRANCH" yaml:"git_branch"`
+	DefaultTemplate      string              `env:"JRNL_TEMPLATE" yaml:"default_template"`
+	Encryption           string              `env:"JRNL_ENCRYPTION" yaml:"encryption"`
+	EncryptionKeyFile    string              `env:"JRNL_KEY_FILE" yaml:"encryption_key_file"`
+	DefaultTags          []string            `yaml:"default_tags"`
+	Templates            TemplateRules       `yaml:"templates"`
+	Journal              string              `env:"JRNL_JOURNAL" yaml:"default_journal"`
+	Journals             map[string]string   `yaml:"journals"`
+	CommandDefaults      map[string][]string `yaml:"commands"`
+	// sources records where each setting was loaded from.
+	sources map[string]string
+}
+
+type ConfigCommand struct {
+	options       Configuration
+	flags         *flag.FlagSet
+	consoleWriter *os.File
+}
+
+type setting struct {
+	name  string
+	value *string
+}
+
+func (c *Configuration) settings() []setting {
+	return []setting{
+		{"journal_path", &c.JournalPath},
+		{"editor", &c.JournalEditor},
+		{"editor_options", &c.JournalEditorOptions},
+		{"filename_date_format", &c.FilenameDateFormat},
+		{"git_remote", &c.GitRemote},
+		{"git_branch", &c.GitBranch},
+		{"default_template", &c.DefaultTemplate},
+		{"default_journal", &c.Journal},
+		{"encryption", &c.Encryption},
+		{"encryption_key_file", &c.EncryptionKeyFile},
+	}
+}
+
+// DefaultConfigurationPath returns the XDG location of the configuration file.
+func DefaultConfigurationPath() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	return filepath.Join(configHome, "jrnl", "config.yaml")
+}
+
+func defaultConfiguration() Configuration {
+	return Configuration{
+		JournalPath:        filepath.Join(os.Getenv("HOME"), "journal.wiki"),
+		JournalEditor:      "vim",
+		FilenameDateFormat: entryDateFormat,
+	}
+}
+
+// LoadConfiguration merges the defaults, the configuration file at configPath,
+// the environment and the flag values, each taking precedence over the last.
+// A missing configuration file is only an error when required is set.
+func LoadConfiguration(configPath string, required bool, flags Configuration) (Configuration, error) {
+	config := Configuration{
+		sources: make(map[string]string),
+	}
+	config.merge(defaultConfiguration(), sourceDefault)
+
+	fileConfig := Configuration{}
+	content, err := ioutil.ReadFile(configPath)
+	if err != nil && (required || !os.IsNotExist(err)) {
+		return config, err
+	}
+	if err == nil {
+		if err := yaml.UnmarshalStrict(content, &fileConfig); err != nil {
+			return config, fmt.Errorf("invalid configuration file %s: %v", configPath, err)
+		}
+		fileSource := fmt.Sprintf("%s (%s)", sourceFile, configPath)
+		config.merge(fileConfig, fileSource)
+		if fileConfig.DefaultTags != nil {
+			config.DefaultTags = fileConfig.DefaultTags
+			config.sources["default_tags"] = fileSource
+		}
+		if fileConfig.Templates.Weekdays != nil || fileConfig.Templates.Subjects != nil {
+			config.Templates = fileConfig.Templates
+			config.sources["templates"] = fileSource
+		}
+		if fileConfig.Journals != nil {
+			config.Journals = fileConfig.Journals
+			config.sources["journals"] = fileSource
+		}
+		if fileConfig.CommandDefaults != nil {
+			config.CommandDefaults = fileConfig.CommandDefaults
+			config.sources["commands"] = fileSource
+		}
+	}
+
+	envConfig := Configuration{}
+	if err := env.Parse(&envConfig); err != nil {
+		return config, err
+	}
+	config.merge(envConfig, sourceEnv)
+	config.merge(flags, sourceFlag)
+	if err := config.selectJournal(); err != nil {
+		return config, err
+	}
+	config.JournalPath = expandHome(config.JournalPath)
+	return config, nil
+}
+
+// selectJournal points the journal path at the selected named journal, unless
+// the path was set with a higher precedence than the selection.
+func (c *Configuration) selectJournal() error {
+	if c.Journal == "" {
+		return nil
+	}
+	journalPath, ok := c.Journals[c.Journal]
+	if !ok {
+		return fmt.Errorf("unknown journal %q", c.Journal)
+	}
+	if sourcePrecedence(c.source("default_journal")) >= sourcePrecedence(c.source("journal_path")) {
+		c.JournalPath = journalPath
+		c.sources["journal_path"] = fmt.Sprintf("journal %s", c.Journal)
+	}
+	return nil
+}
+
+func sourcePrecedence(source string) int {
+	switch {
+	case source == sourceFlag:
+		return 3
+	case source == sourceEnv:
+		return 2
+	case strings.HasPrefix(source, sourceFile):
+		return 1
+	}
+	return 0
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(os.Getenv("HOME"), path[1:])
+	}
+	return path
+}
+
+func (c *Configuration) merge(layer Configuration, source string) {
+	layerSettings := layer.settings()
+	for i, setting := range c.settings() {
+		if value := *layerSettings[i].value; value != "" {
+			*setting.value = value
+			c.sources[setting.name] = source
+		}
+	}
+}
+
+func (c Configuration) source(name string) string {
+	if source, ok := c.sources[name]; ok {
+		return source
+	}
+	return sourceDefault
+}
+
+func (c Configuration) filenameDateFormat() string {
+	if c.FilenameDateFormat == "" {
+		return entryDateFormat
+	}
+	return c.FilenameDateFormat
+}
+
+// entryName is the name of the journal entry for a date.
+func (c Configuration) entryName(date time.Time) string {
+	return date.Format(c.filenameDateFormat())
+}
+
+// pushTarget returns the remote and branch to push to. Unless configured,
+// they are the upstream of the current branch, or origin and the current
+// branch when there is no upstream.
+func (c Configuration) pushTarget(status GitStatus) (string, string, error) {
+	remote, branch := "origin", status.Branch
+	if parts := strings.SplitN(status.Upstream, "/", 2); len(parts) == 2 {
+		remote, branch = parts[0], parts[1]
+	}
+	if c.GitRemote != "" {
+		remote = c.GitRemote
+	}
+	if c.GitBranch != "" {
+		branch = c.GitBranch
+	}
+	if branch == "" {
+		return "", "", ErrDetachedHead
+	}
+	return remote, branch, nil
+}
+
+// NewConfigCommand creates a new command runner for inspecting the configuration
+func NewConfigCommand(config Configuration, consoleWriter *os.File) *ConfigCommand {
+	configCommand := ConfigCommand{
+		options:       config,
+		flags:         flag.NewFlagSet("config", flag.ExitOnError),
+		consoleWriter: consoleWriter,
+	}
+	return &configCommand
+}
+
+// Run the config command
+func (c *ConfigCommand) Run(ctx context.Context, subcommandArgs []string) error {
+	if !c.flags.Parsed() {
+		if err := c.flags.Parse(subcommandArgs); err != nil {
+			return err
+		}
+	}
+	commandArgs := c.flags.Args()
+	if len(commandArgs) == 0 || commandArgs[0] != "show" {
+		return errors.New("usage: config show")
+	}
+	writer := tabwriter.NewWriter(c.consoleWriter, 0, 0, 2, ' ', 0)
+	for _, setting := range c.options.settings() {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", setting.name, *setting.value, c.options.source(setting.name))
+	}
+	if len(c.options.DefaultTags) > 0 {
+		fmt.Fprintf(writer, "default_tags\t%s\t%s\n", strings.Join(c.options.DefaultTags, ", "), c.options.source("default_tags"))
+	}
+	for _, rule := range c.options.Templates.rules() {
+		fmt.Fprintf(writer, "templates.%s\t%s\t%s\n", rule[0], rule[1], c.options.source("templates"))
+	}
+	for _, name := range sortedJournalNames(c.options.Journals) {
+		fmt.Fprintf(writer, "journals.%s\t%s\t%s\n", name, c.options.Journals[name], c.options.source("journals"))
+	}
+	commandNames := make([]string, 0, len(c.options.CommandDefaults))
+	for name := range c.options.CommandDefaults {
+		commandNames = append(commandNames, name)
+	}
+	sort.Strings(commandNames)
+	for _, name := range commandNames {
+		fmt.Fprintf(writer, "commands.%s\t%s\t%s\n", name, strings.Join(c.options.CommandDefaults[name], " "), c.options.source("commands"))
+	}
+	return writer.Flush()
 }
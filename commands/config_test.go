@@ -0,0 +1,230 @@
+package commands_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func writeConfigFile(t *testing.T, content string) (string, func()) {
+	directory, err := ioutil.TempDir("", "jrnl-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(directory, "config.yaml")
+	if err := ioutil.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return configPath, func() {
+		os.RemoveAll(directory)
+	}
+}
+
+func setEnv(t *testing.T, values map[string]string) func() {
+	previous := make(map[string]*string)
+	for key, value := range values {
+		if old, ok := os.LookupEnv(key); ok {
+			previous[key] = &old
+		} else {
+			previous[key] = nil
+		}
+		if value == "" {
+			os.Unsetenv(key)
+		} else {
+			os.Setenv(key, value)
+		}
+	}
+	return func() {
+		for key, value := range previous {
+			if value == nil {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, *value)
+			}
+		}
+	}
+}
+
+func TestLoadConfigurationPrecedence(t *testing.T) {
+	configPath, cleanup := writeConfigFile(t, `journal_path: /file/journal
+editor: code
+editor_options: -n
+git_branch: main
+commands:
+  find:
+  - -format
+  - json
+`)
+	defer cleanup()
+	defer setEnv(t, map[string]string{
+		"JOURNAL_PATH":        "",
+		"JRNL_EDITOR":         "nano",
+		"JRNL_EDITOR_OPTIONS": "",
+		"JRNL_DATE_FORMAT":    "",
+		"JRNL_GIT_REMOTE":     "",
+		"JRNL_GIT_BRANCH":     "",
+		"JRNL_TEMPLATE":       "",
+		"JRNL_JOURNAL":        "",
+	})()
+	config, err := commands.LoadConfiguration(configPath, true, commands.Configuration{
+		JournalPath: "/flag/journal",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"JournalPath":          "/flag/journal",
+		"JournalEditor":        "nano",
+		"JournalEditorOptions": "-n",
+		"FilenameDateFormat":   "2006-01-02",
+		"GitRemote":            "",
+		"GitBranch":            "main",
+	}
+	values := reflect.ValueOf(config)
+	for field, value := range expected {
+		if actual := values.FieldByName(field).String(); actual != value {
+			t.Errorf("Expected %v to be %v, got %v", field, value, actual)
+		}
+	}
+	if !reflect.DeepEqual(config.CommandDefaults["find"], []string{"-format", "json"}) {
+		t.Errorf("Expected find defaults, got %v", config.CommandDefaults["find"])
+	}
+}
+
+func TestLoadConfigurationMissingFile(t *testing.T) {
+	if _, err := commands.LoadConfiguration("/does/not/exist.yaml", false, commands.Configuration{}); err != nil {
+		t.Errorf("Expected an optional missing file to be ignored, got %v", err)
+	}
+	if _, err := commands.LoadConfiguration("/does/not/exist.yaml", true, commands.Configuration{}); err == nil {
+		t.Error("Expected a required missing file to produce an error")
+	}
+}
+
+func TestLoadConfigurationUnknownSetting(t *testing.T) {
+	configPath, cleanup := writeConfigFile(t, "journal_pth: /typo\n")
+	defer cleanup()
+	if _, err := commands.LoadConfiguration(configPath, true, commands.Configuration{}); err == nil {
+		t.Error("Expected an unknown setting to produce an error")
+	}
+}
+
+func TestConfigShow(t *testing.T) {
+	configPath, cleanup := writeConfigFile(t, "editor: code\ncommands:\n  find: [-format, json]\n")
+	defer cleanup()
+	defer setEnv(t, map[string]string{
+		"JOURNAL_PATH":        "/env/journal",
+		"JRNL_EDITOR":         "",
+		"JRNL_EDITOR_OPTIONS": "",
+		"JRNL_DATE_FORMAT":    "",
+		"JRNL_GIT_REMOTE":     "",
+		"JRNL_GIT_BRANCH":     "",
+		"JRNL_TEMPLATE":       "",
+		"JRNL_JOURNAL":        "",
+		"JRNL_ENCRYPTION":     "",
+		"JRNL_KEY_FILE":       "",
+	})()
+	config, err := commands.LoadConfiguration(configPath, true, commands.Configuration{GitRemote: "upstream"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, w, _ := os.Pipe()
+	cmd := commands.NewConfigCommand(config, w)
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 1, 0, 0, 0, 0, time.UTC))
+	if err := cmd.Run(ctx, []string{"show"}); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	output, _ := ioutil.ReadAll(r)
+	expectedOutput := fmt.Sprintf(`journal_path          /env/journal  env
+editor                code          file (%[1]v)
+editor_options                      default
+filename_date_format  2006-01-02    default
+git_remote            upstream      flag
+git_branch                          default
+default_template                    default
+default_journal                     default
+encryption                          default
+encryption_key_file                 default
+commands.find         -format json  file (%[1]v)
+`, configPath)
+	if expectedOutput != string(output) {
+		t.Errorf("Expected %v, got %v", expectedOutput, string(output))
+	}
+}
+
+func TestLoadConfigurationJournals(t *testing.T) {
+	configPath, cleanup := writeConfigFile(t, `journal_path: /file/journal
+default_journal: work
+journals:
+  work: /work/journal
+  personal: /personal/journal
+`)
+	defer cleanup()
+	inputs := []struct {
+		name         string
+		env          map[string]string
+		flags        commands.Configuration
+		expected     string
+		expectsError bool
+	}{
+		{"file default", map[string]string{}, commands.Configuration{}, "/work/journal", false},
+		{"env selection", map[string]string{"JRNL_JOURNAL": "personal"}, commands.Configuration{}, "/personal/journal", false},
+		{"env path over file selection", map[string]string{"JOURNAL_PATH": "/env/journal"}, commands.Configuration{}, "/env/journal", false},
+		{"flag selection over env path", map[string]string{"JOURNAL_PATH": "/env/journal"}, commands.Configuration{Journal: "personal"}, "/personal/journal", false},
+		{"flag selection over flag path", map[string]string{}, commands.Configuration{Journal: "personal", JournalPath: "/flag/journal"}, "/personal/journal", false},
+		{"unknown journal", map[string]string{}, commands.Configuration{Journal: "missing"}, "", true},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			env := map[string]string{"JOURNAL_PATH": "", "JRNL_JOURNAL": ""}
+			for key, value := range input.env {
+				env[key] = value
+			}
+			defer setEnv(t, env)()
+			config, err := commands.LoadConfiguration(configPath, true, input.flags)
+			if err != nil && !input.expectsError {
+				t.Fatal(err)
+			} else if err == nil && input.expectsError {
+				t.Fatal("Expected input to produce an error")
+			} else if err != nil && input.expectsError {
+				return
+			}
+			if config.JournalPath != input.expected {
+				t.Errorf("Expected %v, got %v", input.expected, config.JournalPath)
+			}
+		})
+	}
+}
+
+func TestJournals(t *testing.T) {
+	path, _ := filepath.Abs("../fixtures")
+	config := commands.Configuration{
+		JournalPath: path,
+		Journal:     "work",
+		Journals: map[string]string{
+			"work":     path,
+			"personal": "/does/not/exist",
+		},
+	}
+	r, w, _ := os.Pipe()
+	cmd := commands.NewJournalsCommand(config, w)
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 1, 0, 0, 0, 0, time.UTC))
+	if err := cmd.Run(ctx, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	output, _ := ioutil.ReadAll(r)
+	padding := strings.Repeat(" ", len(path)-len("/does/not/exist"))
+	expectedOutput := fmt.Sprintf("   personal  /does/not/exist%v  -\n*  work      %v  5 entries\n", padding, path)
+	if expectedOutput != string(output) {
+		t.Errorf("Expected %q, got %q", expectedOutput, string(output))
+	}
+}
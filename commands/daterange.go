@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const entryDateFormat = "2006-01-02"
+
+// dateRange is a period of time starting at since and ending before until.
+// A zero bound leaves that side of the range open.
+type dateRange struct {
+	since time.Time
+	until time.Time
+	// filenameFormat is used to date entries without a frontmatter date.
+	filenameFormat string
+}
+
+type dateRangeFlags struct {
+	since *string
+	until *string
+}
+
+func newDateRangeFlags(flags *flag.FlagSet) dateRangeFlags {
+	return dateRangeFlags{
+		since: flags.String("since", "", "Only include entries on or after a date (YYYY-MM-DD, 7d, 2w, 3m, 1y, today, yesterday, this-week, last-month, ...)."),
+		until: flags.String("until", "", "Only include entries on or before a date (same formats as -since)."),
+	}
+}
+
+func (d dateRangeFlags) parse(ctx context.Context, config Configuration) (dateRange, error) {
+	period, err := parseDateRange(*d.since, *d.until, ctx.Value(CommandContextKey("date")).(time.Time))
+	period.filenameFormat = config.filenameDateFormat()
+	return period, err
+}
+
+func parseDateRange(since, until string, now time.Time) (dateRange, error) {
+	var period dateRange
+	if since != "" {
+		start, _, err := parseDateExpression(since, now)
+		if err != nil {
+			return period, err
+		}
+		period.since = start
+	}
+	if until != "" {
+		_, end, err := parseDateExpression(until, now)
+		if err != nil {
+			return period, err
+		}
+		period.until = end
+	}
+	return period, nil
+}
+
+// parseDateExpression resolves an absolute or relative date expression to the
+// period it describes, ie: "last-month" is the first of last month up to the
+// first of this month.
+func parseDateExpression(expression string, now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	day := func(start time.Time) (time.Time, time.Time, error) {
+		return start, start.AddDate(0, 0, 1), nil
+	}
+	weekStart := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+	yearStart := time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, today.Location())
+	switch strings.ToLower(expression) {
+	case "today":
+		return day(today)
+	case "yesterday":
+		return day(today.AddDate(0, 0, -1))
+	case "this-week":
+		return weekStart, weekStart.AddDate(0, 0, 7), nil
+	case "last-week":
+		return weekStart.AddDate(0, 0, -7), weekStart, nil
+	case "this-month":
+		return monthStart, monthStart.AddDate(0, 1, 0), nil
+	case "last-month":
+		return monthStart.AddDate(0, -1, 0), monthStart, nil
+	case "this-year":
+		return yearStart, yearStart.AddDate(1, 0, 0), nil
+	case "last-year":
+		return yearStart.AddDate(-1, 0, 0), yearStart, nil
+	}
+	if date, err := time.ParseInLocation(entryDateFormat, expression, now.Location()); err == nil {
+		return day(date)
+	}
+	if len(expression) > 1 {
+		amount, err := strconv.Atoi(expression[:len(expression)-1])
+		if err == nil && amount >= 0 {
+			switch expression[len(expression)-1] {
+			case 'd':
+				return day(today.AddDate(0, 0, -amount))
+			case 'w':
+				return day(today.AddDate(0, 0, -7*amount))
+			case 'm':
+				return day(today.AddDate(0, -amount, 0))
+			case 'y':
+				return day(today.AddDate(-amount, 0, 0))
+			}
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("unable to parse date %q", expression)
+}
+
+func (d dateRange) isSet() bool {
+	return !d.since.IsZero() || !d.until.IsZero()
+}
+
+// includes reports whether the entry falls within the range. Entries without
+// a known date are only included when the range is unbounded.
+func (d dateRange) includes(entry *entryHeader) bool {
+	if !d.isSet() {
+		return true
+	}
+	date, ok := entryDate(entry, d.filenameFormat, d.location())
+	if !ok {
+		return false
+	}
+	if !d.since.IsZero() && date.Before(d.since) {
+		return false
+	}
+	if !d.until.IsZero() && !date.Before(d.until) {
+		return false
+	}
+	return true
+}
+
+func (d dateRange) location() *time.Location {
+	if !d.since.IsZero() {
+		return d.since.Location()
+	}
+	return d.until.Location()
+}
+
+// entryDate returns the frontmatter date of an entry, falling back to a date
+// in the entry filename.
+func entryDate(entry *entryHeader, filenameFormat string, location *time.Location) (time.Time, bool) {
+	if !entry.Date().IsZero() {
+		return entry.Date(), true
+	}
+	date, err := time.ParseInLocation(filenameFormat, strings.TrimSuffix(path.Base(entry.Filename), ".md"), location)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return date, true
+}
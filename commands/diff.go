@@ -0,0 +1,232 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+type DiffCommand struct {
+	options       Configuration
+	flags         *flag.FlagSet
+	store         EntryStore
+	runner        GitCommandRunner
+	consoleWriter *os.File
+}
+
+type diffLine struct {
+	// kind is ' ' for an unchanged line, '-' for a deleted line and '+' for
+	// an added line.
+	kind byte
+	text string
+}
+
+// NewDiffCommand creates a new command runner for comparing revisions of an entry
+func NewDiffCommand(config Configuration, store EntryStore, runner GitCommandRunner, consoleWriter *os.File) *DiffCommand {
+	diffCommand := DiffCommand{
+		options:       config,
+		flags:         flag.NewFlagSet("diff", flag.ExitOnError),
+		store:         store,
+		runner:        runner,
+		consoleWriter: consoleWriter,
+	}
+	return &diffCommand
+}
+
+// Run the diff command
+func (d *DiffCommand) Run(ctx context.Context, subcommandArgs []string) error {
+	selected := newEntryFlags(d.flags)
+	if !d.flags.Parsed() {
+		if err := d.flags.Parse(subcommandArgs); err != nil {
+			return err
+		}
+	}
+	revisions := d.flags.Args()
+	if len(revisions) > 2 {
+		return errors.New("diff compares at most two revisions")
+	}
+	// The last memorized revision is compared to the entry by default.
+	from, to := "HEAD", ""
+	if len(revisions) > 0 {
+		from = revisions[0]
+	}
+	if len(revisions) > 1 {
+		to = revisions[1]
+	}
+	name, err := selected.name(ctx, d.options)
+	if err != nil {
+		return err
+	}
+	j, err := openJournal(d.options, d.store)
+	if err != nil {
+		return err
+	}
+	before, err := d.revision(j, name, from)
+	if err != nil {
+		return err
+	}
+	after, err := d.revision(j, name, to)
+	if err != nil {
+		return err
+	}
+	lines := diffLines(before, after)
+	if len(lines) == 0 {
+		return nil
+	}
+	fmt.Fprintf(d.consoleWriter, "--- %s\n+++ %s\n", revisionLabel(name, from), revisionLabel(name, to))
+	for _, line := range lines {
+		fmt.Fprintln(d.consoleWriter, line)
+	}
+	return nil
+}
+
+// revision returns the document of an entry as of a revision, or of the
+// entry in the journal when revision is empty. Encrypted entries are
+// decrypted so the change to their content shows.
+func (d *DiffCommand) revision(j *journal, name string, revision string) (string, error) {
+	var content []byte
+	var err error
+	if revision == "" {
+		content, err = d.store.Read(name)
+	} else {
+		content, err = d.runner.Show(d.options.JournalPath, revision, entryRepositoryPath(name))
+	}
+	if err != nil {
+		return "", err
+	}
+	entry, err := j.parse(name, content)
+	if err != nil || !entry.encrypted && !entry.locked {
+		return string(content), nil
+	}
+	if entry.locked {
+		return "", errMissingKey
+	}
+	document, err := entry.MarshalFrontmatter()
+	return string(document), err
+}
+
+func revisionLabel(name string, revision string) string {
+	if revision == "" {
+		return entryRepositoryPath(name)
+	}
+	return revision + ":" + entryRepositoryPath(name)
+}
+
+// diffLines compares two documents line by line, returning the changes in
+// the unified format with diffContext lines of context.
+func diffLines(before string, after string) []string {
+	changes := diffDocuments(splitLines(before), splitLines(after))
+	var output []string
+	for start := 0; start < len(changes); {
+		if changes[start].kind == ' ' {
+			start++
+			continue
+		}
+		// Extend the hunk while the next change is close enough to share context.
+		end := start + 1
+		for next := end; next < len(changes) && next-end <= 2*diffContext; next++ {
+			if changes[next].kind != ' ' {
+				end = next + 1
+			}
+		}
+		first := start - diffContext
+		if first < 0 {
+			first = 0
+		}
+		last := end + diffContext
+		if last > len(changes) {
+			last = len(changes)
+		}
+		output = append(output, hunkHeader(changes, first, last))
+		for _, change := range changes[first:last] {
+			output = append(output, string(change.kind)+change.text)
+		}
+		start = last
+	}
+	return output
+}
+
+func hunkHeader(changes []diffLine, first int, last int) string {
+	oldStart, newStart := 1, 1
+	for _, change := range changes[:first] {
+		if change.kind != '+' {
+			oldStart++
+		}
+		if change.kind != '-' {
+			newStart++
+		}
+	}
+	var oldCount, newCount int
+	for _, change := range changes[first:last] {
+		if change.kind != '+' {
+			oldCount++
+		}
+		if change.kind != '-' {
+			newCount++
+		}
+	}
+	// An empty side is numbered by the line before it.
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, oldCount, newStart, newCount)
+}
+
+// diffDocuments returns the shortest edit from before to after, using the
+// longest common subsequence of their lines.
+func diffDocuments(before []string, after []string) []diffLine {
+	common := make([][]int, len(before)+1)
+	for i := range common {
+		common[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for k := len(after) - 1; k >= 0; k-- {
+			if before[i] == after[k] {
+				common[i][k] = common[i+1][k+1] + 1
+			} else if common[i+1][k] >= common[i][k+1] {
+				common[i][k] = common[i+1][k]
+			} else {
+				common[i][k] = common[i][k+1]
+			}
+		}
+	}
+	var changes []diffLine
+	i, k := 0, 0
+	for i < len(before) && k < len(after) {
+		switch {
+		case before[i] == after[k]:
+			changes = append(changes, diffLine{' ', before[i]})
+			i++
+			k++
+		case common[i+1][k] >= common[i][k+1]:
+			changes = append(changes, diffLine{'-', before[i]})
+			i++
+		default:
+			changes = append(changes, diffLine{'+', after[k]})
+			k++
+		}
+	}
+	for ; i < len(before); i++ {
+		changes = append(changes, diffLine{'-', before[i]})
+	}
+	for ; k < len(after); k++ {
+		changes = append(changes, diffLine{'+', after[k]})
+	}
+	return changes
+}
+
+func splitLines(document string) []string {
+	if document == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(document, "\n"), "\n")
+}
@@ -0,0 +1,69 @@
+package commands_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func TestDiff(t *testing.T) {
+	store := fixtureStore(t)
+	runner := newFakeGitCommand()
+	working := readEntry(t, store, "2018-08-03")
+	runner.files["HEAD:entries/2018-08-03.md"] = strings.Replace(working, "- resolved\n", "", 1)
+	runner.files["abc123:entries/2018-08-03.md"] = strings.Replace(runner.files["HEAD:entries/2018-08-03.md"], "- incident\n", "", 1)
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 3, 0, 0, 0, 0, time.UTC))
+	inputs := []struct {
+		name         string
+		args         []string
+		expected     string
+		expectsError bool
+	}{
+		{"working copy", []string{}, "--- HEAD:entries/2018-08-03.md\n+++ entries/2018-08-03.md\n@@ -3,5 +3,6 @@\n tags:\n - incident\n - db\n+- resolved\n ---\n Raised the pool size and the incident is resolved.\n", false},
+		{"revisions", []string{"abc123", "HEAD"}, "--- abc123:entries/2018-08-03.md\n+++ HEAD:entries/2018-08-03.md\n@@ -1,6 +1,7 @@\n ---\n date: Fri Aug 3 2018 00:00:00 +0000 UTC\n tags:\n+- incident\n - db\n ---\n Raised the pool size and the incident is resolved.\n", false},
+		{"unchanged", []string{"HEAD", "HEAD"}, "", false},
+		{"missing revision", []string{"HEAD~5"}, "", true},
+		{"too many revisions", []string{"HEAD~2", "HEAD~1", "HEAD"}, "", true},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			r, w, _ := os.Pipe()
+			cmd := commands.NewDiffCommand(commands.Configuration{JournalPath: "/some/path"}, store, runner, w)
+			err := cmd.Run(ctx, input.args)
+			if input.expectsError != (err != nil) {
+				t.Errorf("Expected error %v, got %v", input.expectsError, err)
+			}
+			w.Close()
+			output, _ := ioutil.ReadAll(r)
+			if input.expected != string(output) {
+				t.Errorf("Expected %q, got %q", input.expected, string(output))
+			}
+		})
+	}
+
+	t.Run("encrypted", func(t *testing.T) {
+		defer setEnv(t, map[string]string{"JRNL_PASSPHRASE": "correct horse"})()
+		config := commands.Configuration{JournalPath: "/some/path", Encryption: "body"}
+		if err := commands.NewWriteCommand(config, store, os.Stdin).Run(ctx, []string{"Deployed"}); err != nil {
+			t.Fatal(err)
+		}
+		runner.files["HEAD:entries/2018-08-03.md"] = readEntry(t, store, "2018-08-03")
+		if err := commands.NewWriteCommand(config, store, os.Stdin).Run(ctx, []string{"Rolled back"}); err != nil {
+			t.Fatal(err)
+		}
+		r, w, _ := os.Pipe()
+		if err := commands.NewDiffCommand(config, store, runner, w).Run(ctx, []string{}); err != nil {
+			t.Fatal(err)
+		}
+		w.Close()
+		output, _ := ioutil.ReadAll(r)
+		if !strings.HasSuffix(string(output), " - 00:00 Deployed\n+- 00:00 Rolled back\n") {
+			t.Errorf("Expected the decrypted change, got %q", string(output))
+		}
+	})
+}
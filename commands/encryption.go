@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	encryptionNone = "none"
+	// encryptionBody keeps tags and date in clear so entries can be indexed without the key.
+	encryptionBody = "body"
+	// encryptionFull encrypts the whole entry, frontmatter included.
+	encryptionFull = "full"
+
+	encryptedField  = "encrypted"
+	encryptedMethod = "secretbox"
+	saltLength      = 16
+	nonceLength     = 24
+	armorWidth      = 76
+)
+
+var (
+	errMissingKey = errors.New("entry is encrypted, set $JRNL_PASSPHRASE or encryption_key_file")
+	errSealingKey = errors.New("encryption is enabled, set $JRNL_PASSPHRASE or encryption_key_file")
+)
+
+// entryCipher seals whole entry documents with NaCl secretbox, using a key
+// derived from a passphrase with scrypt.
+type entryCipher struct {
+	passphrase []byte
+	mode       string
+	mutex      sync.Mutex
+	keys       map[string]*derivedKey
+	salt       []byte
+}
+
+// derivedKey is the key of a salt, ready once its derivation is done.
+type derivedKey struct {
+	ready chan struct{}
+	key   *[32]byte
+	err   error
+}
+
+// newEntryCipher creates the cipher configured for the journal. The cipher
+// has no key when no passphrase is configured, in which case encrypted
+// entries can only be read for their clear metadata and nothing can be
+// written when encryption is enabled.
+func newEntryCipher(config Configuration) (*entryCipher, error) {
+	mode := config.Encryption
+	switch mode {
+	case "", encryptionNone:
+		mode = encryptionNone
+	case encryptionBody, encryptionFull:
+	default:
+		return nil, fmt.Errorf("unknown encryption mode %q", config.Encryption)
+	}
+	cipher := &entryCipher{
+		mode: mode,
+		keys: make(map[string]*derivedKey),
+	}
+	if passphrase := os.Getenv("JRNL_PASSPHRASE"); passphrase != "" {
+		cipher.passphrase = []byte(passphrase)
+	} else if config.EncryptionKeyFile != "" {
+		content, err := ioutil.ReadFile(expandHome(config.EncryptionKeyFile))
+		if err != nil {
+			return nil, err
+		}
+		cipher.passphrase = []byte(strings.TrimSpace(string(content)))
+	}
+	return cipher, nil
+}
+
+// key derives the key of a salt once. Keys of different salts are derived
+// concurrently, and readers of a salt being derived wait for it.
+func (c *entryCipher) key(salt []byte) (*[32]byte, error) {
+	if len(c.passphrase) == 0 {
+		return nil, errMissingKey
+	}
+	c.mutex.Lock()
+	derived, ok := c.keys[string(salt)]
+	if !ok {
+		derived = &derivedKey{ready: make(chan struct{})}
+		c.keys[string(salt)] = derived
+	}
+	c.mutex.Unlock()
+	if ok {
+		<-derived.ready
+		return derived.key, derived.err
+	}
+	defer close(derived.ready)
+	key, err := scrypt.Key(c.passphrase, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		derived.err = err
+		return nil, err
+	}
+	derived.key = new([32]byte)
+	copy(derived.key[:], key)
+	// Reuse a salt already in the journal so a single key derivation covers
+	// the entries written in this session.
+	c.mutex.Lock()
+	if c.salt == nil {
+		c.salt = salt
+	}
+	c.mutex.Unlock()
+	return derived.key, nil
+}
+
+func (c *entryCipher) sessionSalt() ([]byte, error) {
+	c.mutex.Lock()
+	salt := c.salt
+	c.mutex.Unlock()
+	if salt != nil {
+		return salt, nil
+	}
+	salt = make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// encrypt seals a complete entry document, returning the document to store.
+func (c *entryCipher) encrypt(document []byte) ([]byte, error) {
+	entry, err := unmarshalFrontmatter(document)
+	if err != nil {
+		return nil, err
+	}
+	salt, err := c.sessionSalt()
+	if err != nil {
+		return nil, err
+	}
+	key, err := c.key(salt)
+	if err != nil {
+		return nil, err
+	}
+	var nonce [nonceLength]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	sealed := append(append([]byte{}, salt...), nonce[:]...)
+	sealed = secretbox.Seal(sealed, document, &nonce, key)
+	stored := entryHeader{
+		Content: armor(sealed),
+	}
+	if c.mode != encryptionFull {
+		if date := entry.Date(); !date.IsZero() {
+			stored.SetDate(date)
+		}
+		stored.SetTags(entry.Tags())
+	}
+	stored.SetField(encryptedField, encryptedMethod)
+	return stored.MarshalFrontmatter()
+}
+
+// decrypt opens an entry stored by encrypt. Entries that are not encrypted
+// are returned unchanged. Without a key, only the clear metadata of an
+// encrypted entry is returned and the entry is locked against writes.
+func (c *entryCipher) decrypt(stored *entryHeader) (*entryHeader, error) {
+	if method, ok := stored.Field(encryptedField); !ok || method != encryptedMethod {
+		return stored, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(stored.Content), ""))
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < saltLength+nonceLength+secretbox.Overhead {
+		return nil, errors.New("encrypted entry is truncated")
+	}
+	key, err := c.key(sealed[:saltLength])
+	if err == errMissingKey {
+		stored.Content = ""
+		stored.DeleteField(encryptedField)
+		stored.locked = true
+		return stored, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var nonce [nonceLength]byte
+	copy(nonce[:], sealed[saltLength:saltLength+nonceLength])
+	document, ok := secretbox.Open(nil, sealed[saltLength+nonceLength:], &nonce, key)
+	if !ok {
+		return nil, errors.New("unable to decrypt entry, wrong passphrase?")
+	}
+	entry, err := unmarshalFrontmatter(document)
+	if err != nil {
+		return nil, err
+	}
+	entry.encrypted = true
+	return entry, nil
+}
+
+func (c *entryCipher) shouldEncrypt(entry *entryHeader) bool {
+	return c.mode != encryptionNone || entry.encrypted
+}
+
+func armor(data []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var lines []string
+	for len(encoded) > armorWidth {
+		lines = append(lines, encoded[:armorWidth])
+		encoded = encoded[armorWidth:]
+	}
+	lines = append(lines, encoded)
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,121 @@
+package commands_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func TestEncryptedEntries(t *testing.T) {
+	store := fixtureStore(t)
+	defer setEnv(t, map[string]string{"JRNL_PASSPHRASE": "correct horse"})()
+	config := commands.Configuration{
+		Encryption: "body",
+	}
+	entryPath := "entries/2018-08-07.md"
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 14, 30, 0, 0, time.UTC))
+	if err := commands.NewWriteCommand(config, store, os.Stdin).Run(ctx, []string{"-t", "deploy", "Deployed", "v1.2"}); err != nil {
+		t.Fatal(err)
+	}
+	stored := readEntry(t, store, "2018-08-07")
+	if strings.Contains(stored, "Deployed") {
+		t.Errorf("Expected content to be encrypted, got %v", stored)
+	}
+	expectedHeader := "---\ndate: Tue Aug 7 2018 14:30:00 +0000 UTC\ntags:\n- deploy\nencrypted: secretbox\n---\n"
+	if !strings.HasPrefix(stored, expectedHeader) {
+		t.Errorf("Expected clear metadata %v, got %v", expectedHeader, stored)
+	}
+
+	inputs := []struct {
+		name       string
+		passphrase string
+		args       []string
+		expected   string
+	}{
+		{"content with key", "correct horse", []string{"-q", "Deployed"}, fmt.Sprintf("%v:6: - 14:30 Deployed v1.2\n", entryPath)},
+		{"tag without key", "", []string{"-tag", "deploy"}, fmt.Sprintf("%v\n", entryPath)},
+		{"content without key", "", []string{"-q", "Deployed"}, ""},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			defer setEnv(t, map[string]string{"JRNL_PASSPHRASE": input.passphrase})()
+			r, w, _ := os.Pipe()
+			cmd := commands.NewFindCommand(config, store, w)
+			if err := cmd.Run(ctx, input.args); err != nil {
+				t.Fatal(err)
+			}
+			w.Close()
+			output, _ := ioutil.ReadAll(r)
+			if input.expected != string(output) {
+				t.Errorf("Expected %q, got %q", input.expected, string(output))
+			}
+		})
+	}
+
+	t.Run("locked without key", func(t *testing.T) {
+		defer setEnv(t, map[string]string{"JRNL_PASSPHRASE": ""})()
+		cmd := commands.NewTagCommand(config, store)
+		if err := cmd.Run(ctx, []string{"-t", "release"}); err == nil {
+			t.Error("Expected tagging an encrypted entry without a key to fail")
+		}
+		if err := commands.NewWriteCommand(config, store, os.Stdin).Run(ctx, []string{"Rolled back"}); err == nil {
+			t.Error("Expected writing with encryption enabled and no key to fail")
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		defer setEnv(t, map[string]string{"JRNL_PASSPHRASE": "battery staple"})()
+		if err := commands.NewWriteCommand(config, store, os.Stdin).Run(ctx, []string{"Rolled back"}); err == nil {
+			t.Error("Expected the wrong passphrase to fail")
+		}
+	})
+
+	if err := commands.NewTagCommand(config, store).Run(ctx, []string{"-t", "release"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := commands.NewWriteCommand(config, store, os.Stdin).Run(ctx, []string{"Rolled back"}); err != nil {
+		t.Fatal(err)
+	}
+	r, w, _ := os.Pipe()
+	if err := commands.NewFindCommand(config, store, w).Run(ctx, []string{"-tag", "release", "-regex", "."}); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	output, _ := ioutil.ReadAll(r)
+	expectedOutput := fmt.Sprintf("%[1]v:7: - 14:30 Deployed v1.2\n%[1]v:8: - 14:30 Rolled back\n", entryPath)
+	if expectedOutput != string(output) {
+		t.Errorf("Expected %q, got %q", expectedOutput, string(output))
+	}
+}
+
+func TestFullEncryption(t *testing.T) {
+	store := fixtureStore(t)
+	defer setEnv(t, map[string]string{"JRNL_PASSPHRASE": "correct horse"})()
+	config := commands.Configuration{
+		Encryption: "full",
+	}
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 1, 0, 0, 0, 0, time.UTC))
+	if err := commands.NewTagCommand(config, store).Run(ctx, []string{"-t", "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	stored := readEntry(t, store, "2018-08-01")
+	if !strings.HasPrefix(stored, "---\nencrypted: secretbox\n---\n") || strings.Contains(stored, "secret\n") {
+		t.Errorf("Expected the frontmatter to be encrypted, got %v", stored)
+	}
+	r, w, _ := os.Pipe()
+	if err := commands.NewFindCommand(config, store, w).Run(ctx, []string{"-tag", "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	output, _ := ioutil.ReadAll(r)
+	expectedOutput := "entries/2018-08-01.md\n"
+	if expectedOutput != string(output) {
+		t.Errorf("Expected %q, got %q", expectedOutput, string(output))
+	}
+}
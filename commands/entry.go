@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ericaro/frontmatter"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const JournalTimeformat = "Mon Jan 2 2006 15:04:05 -0700 MST"
+
+const (
+	tagsField = "tags"
+	dateField = "date"
+)
+
+// entryHeader is a journal entry. Every frontmatter field is kept in its
+// original order so that rewriting an entry only changes the fields that
+// were explicitly set.
+type entryHeader struct {
+	Filepath string
+	Filename string
+	Content  string
+	// name identifies the entry in its store.
+	name string
+	// contentLine is the 1-based line of the file on which Content begins.
+	contentLine int
+	fields      yaml.MapSlice
+	// encrypted is set when the entry was decrypted, so it is encrypted again when written.
+	encrypted bool
+	// locked is set when the entry is encrypted and could not be decrypted.
+	locked bool
+}
+
+// frontmatterDocument adapts an ordered set of fields to the frontmatter package.
+type frontmatterDocument struct {
+	Fields  yaml.MapSlice
+	Content string `fm:"content"`
+}
+
+func (d *frontmatterDocument) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return unmarshal(&d.Fields)
+}
+
+func (d *frontmatterDocument) MarshalYAML() (interface{}, error) {
+	return d.Fields, nil
+}
+
+// normalizeFrontmatter converts the line endings of a CRLF document to LF and
+// ends a document made only of frontmatter with a newline, as the
+// frontmatter parser expects.
+func normalizeFrontmatter(input []byte) []byte {
+	if bytes.HasPrefix(input, []byte("---\r\n")) {
+		input = bytes.Replace(input, []byte("\r\n"), []byte("\n"), -1)
+	}
+	if bytes.HasPrefix(input, []byte("---\n")) && bytes.HasSuffix(input, []byte("\n---")) && !bytes.Contains(input, []byte("\n---\n")) {
+		input = append(input[:len(input):len(input)], '\n')
+	}
+	return input
+}
+
+func (e *entryHeader) MarshalFrontmatter() ([]byte, error) {
+	if len(e.fields) == 0 {
+		return []byte(e.Content), nil
+	}
+	return frontmatter.Marshal(&frontmatterDocument{
+		Fields:  e.fields,
+		Content: e.Content,
+	})
+}
+
+func unmarshalFrontmatter(input []byte) (*entryHeader, error) {
+	entry := parseFrontmatter(input)
+	if _, err := entry.parseDate(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// parseFrontmatter reads the fields and content of a document without
+// checking their values. A document whose frontmatter can not be parsed is
+// read as content without fields, so it is kept as is when written back.
+func parseFrontmatter(original []byte) *entryHeader {
+	input := normalizeFrontmatter(original)
+	document := new(frontmatterDocument)
+	if err := frontmatter.Unmarshal(input, document); err != nil {
+		return &entryHeader{Content: string(original), contentLine: 1}
+	}
+	return &entryHeader{
+		Content:     document.Content,
+		contentLine: strings.Count(string(input[:len(input)-len(document.Content)]), "\n") + 1,
+		fields:      document.Fields,
+	}
+}
+
+// Field returns the raw value of a frontmatter field.
+func (e *entryHeader) Field(key string) (interface{}, bool) {
+	for _, item := range e.fields {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+// SetField replaces the value of a frontmatter field in place, or appends
+// the field if the entry does not have it yet.
+func (e *entryHeader) SetField(key string, value interface{}) {
+	for i, item := range e.fields {
+		if item.Key == key {
+			e.fields[i].Value = value
+			return
+		}
+	}
+	e.fields = append(e.fields, yaml.MapItem{Key: key, Value: value})
+}
+
+// DeleteField removes a frontmatter field.
+func (e *entryHeader) DeleteField(key string) {
+	for i, item := range e.fields {
+		if item.Key == key {
+			e.fields = append(e.fields[:i], e.fields[i+1:]...)
+			return
+		}
+	}
+}
+
+// Tags returns the tags of the entry.
+func (e *entryHeader) Tags() []string {
+	value, _ := e.Field(tagsField)
+	switch tags := value.(type) {
+	case []string:
+		return tags
+	case []interface{}:
+		output := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			output = append(output, fmt.Sprint(tag))
+		}
+		return output
+	case string:
+		return []string{tags}
+	}
+	return nil
+}
+
+// SetTags replaces the tags of the entry, removing the field when empty.
+func (e *entryHeader) SetTags(tags []string) {
+	if len(tags) == 0 {
+		e.DeleteField(tagsField)
+		return
+	}
+	e.SetField(tagsField, tags)
+}
+
+// Date returns the frontmatter date of the entry, or the zero time if unset.
+func (e *entryHeader) Date() time.Time {
+	date, _ := e.parseDate()
+	return date
+}
+
+// SetDate replaces the frontmatter date of the entry.
+func (e *entryHeader) SetDate(date time.Time) {
+	e.SetField(dateField, date.Format(JournalTimeformat))
+}
+
+func (e *entryHeader) parseDate() (time.Time, error) {
+	value, _ := e.Field(dateField)
+	switch date := value.(type) {
+	case time.Time:
+		return date, nil
+	case string:
+		if date != "" {
+			return time.Parse(JournalTimeformat, date)
+		}
+	}
+	return time.Time{}, nil
+}
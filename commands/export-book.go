@@ -0,0 +1,178 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"mime"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// markdownImagePattern matches the start of an image link to the bin directory.
+var markdownImagePattern = regexp.MustCompile(`(!\[[^\]]*\]\()bin/`)
+
+const bookTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+{{.Style}}</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Sections}}<section id="{{.Name}}">
+<h2>{{.Heading}}</h2>
+{{.Body}}</section>
+{{end}}</body>
+</html>
+`
+
+// bookSection is an entry of a book.
+type bookSection struct {
+	Name    string
+	Heading string
+	Content string
+	Body    template.HTML
+}
+
+func (e *ExportCommand) book(ctx context.Context, args []string) error {
+	filters := newEntryFilters(e.flags, "Include")
+	title := e.flags.String("title", "Journal", "Title of the book.")
+	format := e.flags.String("format", "md", "Output format: md or html.")
+	output := e.flags.String("o", "", "File to write the book to, instead of the console.")
+	if !e.flags.Parsed() {
+		if err := e.flags.Parse(args); err != nil {
+			return err
+		}
+	}
+	if *format != "md" && *format != "html" {
+		return fmt.Errorf("unknown book format %q, expected md or html", *format)
+	}
+	filter, err := filters.parse(ctx, e.options)
+	if err != nil {
+		return err
+	}
+	entries, err := e.publishedEntries()
+	if err != nil {
+		return err
+	}
+	entries = filter.apply(entries)
+	contents := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		contents[entry.name] = entry.Content
+	}
+	index := newJournalIndex(entries, dateRange{filenameFormat: e.options.filenameDateFormat()})
+	var sections []bookSection
+	for _, entry := range index.chronological() {
+		sections = append(sections, bookSection{
+			Name:    entry.Name,
+			Heading: e.bookHeading(entry),
+			Content: strings.TrimSpace(contents[entry.Name]),
+		})
+	}
+
+	var book []byte
+	if *format == "html" {
+		book, err = e.htmlBook(*title, sections)
+	} else {
+		book = e.markdownBook(*title, sections, *output)
+	}
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = e.consoleWriter.Write(book)
+		return err
+	}
+	return ioutil.WriteFile(*output, book, 0644)
+}
+
+// bookHeading is the date of an entry, preceded by its subject for entries
+// that are not named after their date.
+func (e *ExportCommand) bookHeading(entry indexEntry) string {
+	if entry.Date.IsZero() {
+		return entry.Name
+	}
+	date := entry.Date.Format("Monday, January 2, 2006")
+	if entry.Name == e.options.entryName(entry.Date) {
+		return date
+	}
+	return entry.Name + ", " + date
+}
+
+// markdownBook joins the sections under their headings. Images are linked
+// to the journal's bin directory from where the book is written.
+func (e *ExportCommand) markdownBook(title string, sections []bookSection, output string) []byte {
+	images := filepath.Join(e.options.JournalPath, "bin")
+	if output != "" {
+		if absolute, err := filepath.Abs(images); err == nil {
+			images = absolute
+		}
+		if outputDirectory, err := filepath.Abs(filepath.Dir(output)); err == nil {
+			if relative, err := filepath.Rel(outputDirectory, images); err == nil {
+				images = relative
+			}
+		}
+	}
+	book := fmt.Sprintf("# %s\n", title)
+	for _, section := range sections {
+		content := markdownImagePattern.ReplaceAllStringFunc(section.Content, func(link string) string {
+			return strings.TrimSuffix(link, "bin/") + filepath.ToSlash(images) + "/"
+		})
+		book += fmt.Sprintf("\n## %s\n\n%s\n", section.Heading, content)
+	}
+	return []byte(book)
+}
+
+// htmlBook renders the sections to a single HTML file. Images are embedded
+// and links to entries in the book point to their section.
+func (e *ExportCommand) htmlBook(title string, sections []bookSection) ([]byte, error) {
+	included := make(map[string]bool, len(sections))
+	for _, section := range sections {
+		included[section.Name] = true
+	}
+	var imageErr error
+	for i, section := range sections {
+		body := renderMarkdown(section.Content, false, func(destination string, image bool) string {
+			if image && path.Dir(destination) == "bin" {
+				data, err := e.store.Attachment(path.Base(destination))
+				if err != nil {
+					imageErr = err
+					return destination
+				}
+				mimeType := mime.TypeByExtension(path.Ext(destination))
+				if mimeType == "" {
+					mimeType = "application/octet-stream"
+				}
+				return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+			}
+			if !image && included[destination] {
+				return "#" + destination
+			}
+			return destination
+		})
+		if imageErr != nil {
+			return nil, imageErr
+		}
+		sections[i].Body = template.HTML(body)
+	}
+	bookPage, err := template.New("book").Parse(bookTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var output bytes.Buffer
+	err = bookPage.Execute(&output, struct {
+		Title    string
+		Style    template.CSS
+		Sections []bookSection
+	}{title, template.CSS(htmlStyle), sections})
+	return output.Bytes(), err
+}
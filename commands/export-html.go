@@ -0,0 +1,336 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// htmlLayout is shared by every page of an exported site.
+const htmlLayout = `{{define "layout"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="style.css">
+</head>
+<body>
+<nav><a href="{{archivePage}}">Archive</a> <a href="{{tagsPage}}">Tags</a> <a href="{{searchPage}}">Search</a>{{with todayPage}} <a href="{{.}}">Today</a>{{end}}</nav>
+<main>
+{{template "content" .}}</main>
+</body>
+</html>
+{{end}}`
+
+// htmlPages are the pages of both an exported site and the server.
+var htmlPages = map[string]string{
+	"entry": `{{define "content"}}<article>
+<h1>{{.Title}}</h1>
+<p class="meta">{{if not .Entry.Date.IsZero}}<time datetime="{{.Entry.Date.Format "2006-01-02"}}">{{.Entry.Date.Format "Monday, January 2, 2006"}}</time>{{end}}{{range .Entry.Tags}} <a class="tag" href="{{tagPage .}}">{{.}}</a>{{end}}{{with editPage .Entry.Name}} <a href="{{.}}">Edit</a>{{end}}</p>
+{{.Body}}</article>
+{{end}}`,
+	"archive": `{{define "content"}}<h1>Archive</h1>
+{{range .Groups}}<h2>{{.Heading}}</h2>
+<ul>
+{{range .Entries}}<li><a href="{{entryPage .Name}}">{{.Name}}</a> {{.Summary}}</li>
+{{end}}</ul>
+{{end}}{{end}}`,
+	"tags": `{{define "content"}}<h1>Tags</h1>
+<ul>
+{{range .Groups}}<li><a href="{{tagPage .Heading}}">{{.Heading}}</a> ({{len .Entries}})</li>
+{{end}}</ul>
+{{end}}`,
+	"tag": `{{define "content"}}<h1>{{.Title}}</h1>
+<ul>
+{{range (index .Groups 0).Entries}}<li><a href="{{entryPage .Name}}">{{.Name}}</a> {{.Summary}}</li>
+{{end}}</ul>
+{{end}}`,
+}
+
+// staticPages are the pages only an exported site has.
+var staticPages = map[string]string{
+	"search": `{{define "content"}}<h1>Search</h1>
+<input id="q" type="search" placeholder="Search entries" autofocus>
+<ul id="results"></ul>
+<script src="search-index.js"></script>
+<script src="search.js"></script>
+{{end}}`,
+}
+
+const htmlStyle = `body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.5; margin: 0 auto; max-width: 48em; padding: 1em; color: #24292e; }
+nav a { margin-right: 1em; }
+.meta { color: #586069; }
+.tag { background: #f1f8ff; border-radius: 3px; padding: 0 .4em; text-decoration: none; }
+img { max-width: 100%; }
+pre, code { background: #f6f8fa; }
+#q { font-size: 1.2em; width: 100%; }
+`
+
+const htmlSearch = `var input = document.getElementById("q");
+var results = document.getElementById("results");
+input.addEventListener("input", function () {
+  var terms = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+  results.innerHTML = "";
+  if (!terms.length) {
+    return;
+  }
+  searchIndex.forEach(function (entry) {
+    var text = (entry.name + " " + entry.tags.join(" ") + " " + entry.text).toLowerCase();
+    if (!terms.every(function (term) { return text.indexOf(term) >= 0; })) {
+      return;
+    }
+    var item = document.createElement("li");
+    var link = document.createElement("a");
+    link.href = entry.url;
+    link.textContent = entry.name;
+    item.appendChild(link);
+    item.appendChild(document.createTextNode(" " + entry.summary));
+    results.appendChild(item);
+  });
+});
+`
+
+// siteLinks are the URLs of the pages of a site, which differ between an
+// exported site and the server. Empty URLs are not linked.
+type siteLinks struct {
+	archive string
+	tags    string
+	search  string
+	today   func() string
+	entry   func(name string) string
+	tag     func(tag string) string
+	edit    func(name string) string
+}
+
+var staticLinks = siteLinks{
+	archive: "index.html",
+	tags:    "tags.html",
+	search:  "search.html",
+	today:   func() string { return "" },
+	entry:   func(name string) string { return name + ".html" },
+	tag:     func(tag string) string { return tagPageName(tag) + ".html" },
+	edit:    func(name string) string { return "" },
+}
+
+// htmlPage is the data of a page of a site.
+type htmlPage struct {
+	Title  string
+	Body   template.HTML
+	Entry  indexEntry
+	Groups []htmlGroup
+	// Document, Query, Where, Results and Error are used by the forms of the server.
+	Document string
+	Query    string
+	Where    string
+	Results  []htmlResult
+	Error    string
+}
+
+// htmlGroup is a list of entries under a heading, ie: a month or a tag.
+type htmlGroup struct {
+	Heading string
+	Entries []indexEntry
+}
+
+// htmlResult is an entry found by a search, with the lines that matched.
+type htmlResult struct {
+	Entry    indexEntry
+	Snippets []string
+}
+
+// searchDocument is an entry in the search index of an exported site.
+type searchDocument struct {
+	Name    string   `json:"name"`
+	URL     string   `json:"url"`
+	Tags    []string `json:"tags"`
+	Summary string   `json:"summary"`
+	Text    string   `json:"text"`
+}
+
+func (e *ExportCommand) html(ctx context.Context, args []string) error {
+	output := e.flags.String("o", "", "Directory to write the site to.")
+	periodFlags := newDateRangeFlags(e.flags)
+	if !e.flags.Parsed() {
+		if err := e.flags.Parse(args); err != nil {
+			return err
+		}
+	}
+	if *output == "" {
+		return errors.New("export html requires an output directory, ie: -o site")
+	}
+	period, err := periodFlags.parse(ctx, e.options)
+	if err != nil {
+		return err
+	}
+	entries, err := e.publishedEntries()
+	if err != nil {
+		return err
+	}
+	templates, err := htmlTemplates(staticLinks, staticPages)
+	if err != nil {
+		return err
+	}
+	index := newJournalIndex(entries, period)
+	site := make(map[string][]byte)
+	// Entry pages and generated pages share the directory, so an entry named
+	// like a generated page, ie: index or Tag-db, can not be exported. Names
+	// are compared ignoring case for case-insensitive file systems.
+	rendered := make(map[string]string)
+	render := func(name string, kind string, page htmlPage) error {
+		if existing, ok := rendered[strings.ToLower(name)]; ok {
+			return fmt.Errorf("%q and %q would both be exported to %s, rename the entry", existing, page.Title, name)
+		}
+		rendered[strings.ToLower(name)] = page.Title
+		var content bytes.Buffer
+		if err := templates[kind].ExecuteTemplate(&content, "layout", page); err != nil {
+			return err
+		}
+		site[name] = content.Bytes()
+		return nil
+	}
+
+	contents := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		contents[entry.name] = entry.Content
+	}
+	var documents []searchDocument
+	for _, entry := range index.Entries {
+		body := renderMarkdown(contents[entry.Name], false, func(destination string, image bool) string {
+			if !image && isWikiLink(destination) {
+				return destination + ".html"
+			}
+			return destination
+		})
+		if err := render(entry.Name+".html", "entry", htmlPage{Title: entry.Name, Body: template.HTML(body), Entry: entry}); err != nil {
+			return err
+		}
+		documents = append(documents, searchDocument{
+			Name:    entry.Name,
+			URL:     url.PathEscape(entry.Name) + ".html",
+			Tags:    append([]string{}, entry.Tags...),
+			Summary: entry.Summary,
+			Text:    contents[entry.Name],
+		})
+	}
+	if err := render("index.html", "archive", htmlPage{Title: "Archive", Groups: monthGroups(index)}); err != nil {
+		return err
+	}
+	tags := tagGroups(index)
+	tagNames := make([]string, 0, len(tags))
+	for _, group := range tags {
+		tagNames = append(tagNames, group.Heading)
+	}
+	if err := tagPageCollision(tagNames); err != nil {
+		return err
+	}
+	for _, group := range tags {
+		tag := group.Heading
+		if err := render(tagPageName(tag)+".html", "tag", htmlPage{Title: tag, Groups: []htmlGroup{group}}); err != nil {
+			return err
+		}
+	}
+	if err := render("tags.html", "tags", htmlPage{Title: "Tags", Groups: tags}); err != nil {
+		return err
+	}
+	if err := render("search.html", "search", htmlPage{Title: "Search"}); err != nil {
+		return err
+	}
+	searchIndex, err := json.Marshal(documents)
+	if err != nil {
+		return err
+	}
+	site["search-index.js"] = []byte(fmt.Sprintf("var searchIndex = %s;\n", searchIndex))
+	site["search.js"] = []byte(htmlSearch)
+	site["style.css"] = []byte(htmlStyle)
+
+	if err := os.MkdirAll(filepath.Join(*output, "bin"), os.ModePerm); err != nil {
+		return err
+	}
+	for name, content := range site {
+		if err := ioutil.WriteFile(filepath.Join(*output, name), content, 0644); err != nil {
+			return err
+		}
+	}
+	attachments, err := e.store.Attachments()
+	if err != nil {
+		return err
+	}
+	for _, name := range attachments {
+		content, err := e.store.Attachment(name)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(*output, "bin", name), content, 0644); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(e.consoleWriter, "exported %d entries to %s\n", len(index.Entries), *output)
+	return nil
+}
+
+// monthGroups groups the entries of the index by month, chronologically.
+func monthGroups(index *journalIndex) []htmlGroup {
+	var months []htmlGroup
+	for _, entry := range index.chronological() {
+		heading := "Undated"
+		if !entry.Date.IsZero() {
+			heading = entry.Date.Format("January 2006")
+		}
+		if len(months) == 0 || months[len(months)-1].Heading != heading {
+			months = append(months, htmlGroup{Heading: heading})
+		}
+		months[len(months)-1].Entries = append(months[len(months)-1].Entries, entry)
+	}
+	return months
+}
+
+// tagGroups groups the entries of the index by tag, in tag order.
+func tagGroups(index *journalIndex) []htmlGroup {
+	var tags []htmlGroup
+	entries := index.byName()
+	for _, tag := range sortedTagKeys(index.Tags) {
+		group := htmlGroup{Heading: tag}
+		for _, name := range index.Tags[tag] {
+			group.Entries = append(group.Entries, entries[name])
+		}
+		tags = append(tags, group)
+	}
+	return tags
+}
+
+// htmlTemplates parses the shared pages and the pages of a kind of site,
+// linking pages with the links.
+func htmlTemplates(links siteLinks, pages map[string]string) (map[string]*template.Template, error) {
+	layout, err := template.New("layout").Funcs(template.FuncMap{
+		"archivePage": func() string { return links.archive },
+		"tagsPage":    func() string { return links.tags },
+		"searchPage":  func() string { return links.search },
+		"todayPage":   links.today,
+		"entryPage":   links.entry,
+		"tagPage":     links.tag,
+		"editPage":    links.edit,
+	}).Parse(htmlLayout)
+	if err != nil {
+		return nil, err
+	}
+	templates := make(map[string]*template.Template, len(htmlPages)+len(pages))
+	for _, group := range []map[string]string{htmlPages, pages} {
+		for kind, source := range group {
+			page, err := template.Must(layout.Clone()).Parse(source)
+			if err != nil {
+				return nil, err
+			}
+			templates[kind] = page
+		}
+	}
+	return templates, nil
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+type ExportCommand struct {
+	options       Configuration
+	flags         *flag.FlagSet
+	store         EntryStore
+	consoleWriter *os.File
+}
+
+// NewExportCommand creates a new command runner for exporting the journal to other formats
+func NewExportCommand(config Configuration, store EntryStore, consoleWriter *os.File) *ExportCommand {
+	exportCommand := ExportCommand{
+		options:       config,
+		flags:         flag.NewFlagSet("export", flag.ExitOnError),
+		store:         store,
+		consoleWriter: consoleWriter,
+	}
+	return &exportCommand
+}
+
+// Run the export command
+func (e *ExportCommand) Run(ctx context.Context, subcommandArgs []string) error {
+	if len(subcommandArgs) == 0 {
+		return errors.New("export requires a format: html or book")
+	}
+	switch subcommandArgs[0] {
+	case "html":
+		return e.html(ctx, subcommandArgs[1:])
+	case "book":
+		return e.book(ctx, subcommandArgs[1:])
+	default:
+		return fmt.Errorf("unknown export format %q", subcommandArgs[0])
+	}
+}
+
+// publishedEntries returns the entries that may be exported. Encrypted
+// entries are left out, so they are not published in the clear.
+func (e *ExportCommand) publishedEntries() ([]*entryHeader, error) {
+	j, err := openJournal(e.options, e.store)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := j.entries()
+	if err != nil {
+		return nil, err
+	}
+	var published []*entryHeader
+	for _, entry := range entries {
+		if entry.encrypted || entry.locked {
+			fmt.Fprintf(e.consoleWriter, "skipped encrypted entry %s\n", entry.Filepath)
+			continue
+		}
+		published = append(published, entry)
+	}
+	return published, nil
+}
+
+// renderMarkdown renders the content of an entry to HTML. Link and image
+// destinations are passed through rewrite, so they can point to where the
+// linked entries and images are exported. Safe rendering drops the raw HTML
+// of the entry and links to untrusted protocols, so an entry can not run
+// script on the pages of the server.
+func renderMarkdown(content string, safe bool, rewrite func(destination string, image bool) string) []byte {
+	parser := blackfriday.New(blackfriday.WithExtensions(blackfriday.CommonExtensions))
+	flags := blackfriday.CommonHTMLFlags
+	if safe {
+		flags |= blackfriday.SkipHTML | blackfriday.Safelink
+	}
+	renderer := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{Flags: flags})
+	document := parser.Parse([]byte(content))
+	var output bytes.Buffer
+	document.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && (node.Type == blackfriday.Link || node.Type == blackfriday.Image) {
+			node.LinkData.Destination = []byte(rewrite(string(node.LinkData.Destination), node.Type == blackfriday.Image))
+		}
+		return renderer.RenderNode(&output, node, entering)
+	})
+	return output.Bytes()
+}
+
+// isWikiLink reports whether a link destination is another page of the wiki,
+// ie: "2018-08-02" rather than "https://example.com" or "#today".
+func isWikiLink(destination string) bool {
+	parsed, err := url.Parse(destination)
+	return err == nil && parsed.Scheme == "" && parsed.Host == "" && parsed.Path != "" &&
+		!strings.HasPrefix(parsed.Path, "/") && path.Ext(parsed.Path) == ""
+}
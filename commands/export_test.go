@@ -0,0 +1,186 @@
+package commands_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func TestExportHTML(t *testing.T) {
+	output, err := ioutil.TempDir("", "jrnl-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(output)
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 0, 0, 0, 0, time.UTC))
+	store := fixtureStore(t)
+	store.Write("2018-08-06", []byte("---\ntags:\n- db\n---\nFollow up on [the incident](2018-08-02), see the [runbook](https://example.com/runbook).\n\n![](bin/pixel.png)\n"))
+	store.Attach("pixel.png", []byte("png"))
+	defer setEnv(t, map[string]string{"JRNL_PASSPHRASE": "correct horse"})()
+	config := commands.Configuration{Encryption: "body"}
+	if err := commands.NewWriteCommand(config, store, os.Stdin).Run(ctx, []string{"-t", "secret", "Private"}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, w, _ := os.Pipe()
+	if err := commands.NewExportCommand(commands.Configuration{}, store, w).Run(ctx, []string{"html", "-o", output}); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	console, _ := ioutil.ReadAll(r)
+	expectedConsole := "skipped encrypted entry entries/2018-08-07.md\nexported 6 entries to " + output + "\n"
+	if string(console) != expectedConsole {
+		t.Errorf("Expected %q, got %q", expectedConsole, string(console))
+	}
+
+	inputs := []struct {
+		file     string
+		contains []string
+	}{
+		{"2018-08-06.html", []string{
+			"<title>2018-08-06</title>",
+			`<time datetime="2018-08-06">Monday, August 6, 2018</time> <a class="tag" href="Tag-db.html">db</a>`,
+			`<a href="2018-08-02.html">the incident</a>`,
+			`<a href="https://example.com/runbook">runbook</a>`,
+			`<img src="bin/pixel.png" alt="" />`,
+		}},
+		{"index.html", []string{"<h2>August 2018</h2>", `<li><a href="2018-08-05.html">2018-08-05</a> Vacuumed the reporting database.</li>`}},
+		{"tags.html", []string{`<li><a href="Tag-db.html">db</a> (4)</li>`}},
+		{"Tag-incident.html", []string{`<li><a href="2018-08-04.html">2018-08-04</a> Deploy rolled back after failed health checks.</li>`}},
+		{"search.html", []string{`<script src="search-index.js"></script>`}},
+		{"search-index.js", []string{`var searchIndex = [{"name":"2018-08-01","url":"2018-08-01.html","tags":["foo","bar"]`}},
+		{"bin/pixel.png", []string{"png"}},
+	}
+	for _, input := range inputs {
+		t.Run(input.file, func(t *testing.T) {
+			content, err := ioutil.ReadFile(filepath.Join(output, input.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range input.contains {
+				if !strings.Contains(string(content), expected) {
+					t.Errorf("Expected %q in %s, got %s", expected, input.file, content)
+				}
+			}
+		})
+	}
+	for _, file := range []string{"2018-08-07.html", "Tag-secret.html"} {
+		if _, err := os.Stat(filepath.Join(output, file)); !os.IsNotExist(err) {
+			t.Errorf("Expected the encrypted entry to not be exported, found %s", file)
+		}
+	}
+}
+
+func TestExportHTMLNameCollision(t *testing.T) {
+	output, err := ioutil.TempDir("", "jrnl-site")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(output)
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 0, 0, 0, 0, time.UTC))
+	for _, name := range []string{"Index", "Tag-db"} {
+		t.Run(name, func(t *testing.T) {
+			store := fixtureStore(t)
+			store.Write(name, []byte("Overwritten?\n"))
+			err := commands.NewExportCommand(commands.Configuration{}, store, os.Stdout).Run(ctx, []string{"html", "-o", output})
+			if err == nil || !strings.Contains(err.Error(), name) {
+				t.Errorf("Expected the entry %s to collide with a generated page, got %v", name, err)
+			}
+		})
+	}
+	t.Run("tags", func(t *testing.T) {
+		store := fixtureStore(t)
+		store.Write("2018-08-06", []byte("---\ntags:\n- on call\n- on/call\n---\nPaged twice.\n"))
+		err := commands.NewExportCommand(commands.Configuration{}, store, os.Stdout).Run(ctx, []string{"html", "-o", output})
+		if err == nil || !strings.Contains(err.Error(), "rename one of them with retag") {
+			t.Errorf("Expected the tags to collide, got %v", err)
+		}
+	})
+}
+
+func TestExportBook(t *testing.T) {
+	output, err := ioutil.TempDir("", "jrnl-book")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(output)
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 0, 0, 0, 0, time.UTC))
+	store := fixtureStore(t)
+	store.Write("2018-08-06", []byte("---\ntags:\n- db\n---\nFollow up on [the incident](2018-08-02).\n\n![](bin/pixel.png)\n"))
+	store.Write("Postmortem", []byte("---\ndate: Tue Aug 7 2018 10:00:00 +0000 UTC\ntags:\n- incident\n---\nPool size was too small.\n"))
+	store.Attach("pixel.png", []byte("png"))
+	config := commands.Configuration{JournalPath: filepath.Join(output, "journal")}
+
+	inputs := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			"markdown",
+			[]string{"book", "-tag", "db", "-since", "2018-08-05"},
+			"# Journal\n\n## Sunday, August 5, 2018\n\nVacuumed the reporting database.\n\n## Monday, August 6, 2018\n\nFollow up on [the incident](2018-08-02).\n\n![](" + filepath.Join(output, "journal") + "/bin/pixel.png)\n",
+		},
+		{
+			"where",
+			[]string{"book", "-title", "Incidents", "-where", "incident and not db"},
+			"# Incidents\n\n## Saturday, August 4, 2018\n\nDeploy rolled back after failed health checks.\n\n## Postmortem, Tuesday, August 7, 2018\n\nPool size was too small.\n",
+		},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			r, w, _ := os.Pipe()
+			if err := commands.NewExportCommand(config, store, w).Run(ctx, input.args); err != nil {
+				t.Fatal(err)
+			}
+			w.Close()
+			book, _ := ioutil.ReadAll(r)
+			if input.expected != string(book) {
+				t.Errorf("Expected %q, got %q", input.expected, string(book))
+			}
+		})
+	}
+
+	t.Run("markdown file", func(t *testing.T) {
+		bookPath := filepath.Join(output, "books", "db.md")
+		os.MkdirAll(filepath.Dir(bookPath), os.ModePerm)
+		if err := commands.NewExportCommand(config, store, os.Stdout).Run(ctx, []string{"book", "-tag", "db", "-o", bookPath}); err != nil {
+			t.Fatal(err)
+		}
+		book, err := ioutil.ReadFile(bookPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(book), "![](../journal/bin/pixel.png)") {
+			t.Errorf("Expected images relative to the book, got %s", book)
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		r, w, _ := os.Pipe()
+		if err := commands.NewExportCommand(config, store, w).Run(ctx, []string{"book", "-format", "html", "-since", "2018-08-02"}); err != nil {
+			t.Fatal(err)
+		}
+		w.Close()
+		book, _ := ioutil.ReadAll(r)
+		for _, expected := range []string{
+			"<title>Journal</title>",
+			"<section id=\"2018-08-02\">\n<h2>Thursday, August 2, 2018</h2>",
+			`<a href="#2018-08-02">the incident</a>`,
+			`<img src="data:image/png;base64,cG5n" alt="" />`,
+		} {
+			if !strings.Contains(string(book), expected) {
+				t.Errorf("Expected %q in %s", expected, book)
+			}
+		}
+		if strings.Index(string(book), "August 2, 2018") > strings.Index(string(book), "August 6, 2018") {
+			t.Error("Expected the entries in chronological order")
+		}
+	})
+}
@@ -2,16 +2,26 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"regexp"
 	"sort"
 	"strings"
+	"unicode/utf8"
+)
+
+const (
+	highlightStart = "\x1b[1;31m"
+	highlightEnd   = "\x1b[0m"
+	snippetWidth   = 80
 )
 
 type FindCommand struct {
 	options       Configuration
 	flags         *flag.FlagSet
+	store         EntryStore
 	consoleWriter *os.File
 }
 
@@ -26,44 +36,259 @@ func (a *arrayFlags) Set(value string) error {
 	return nil
 }
 
+// entryFilters are the flags selecting entries by tag and date, shared by
+// commands that operate on a selection of entries.
+type entryFilters struct {
+	tags   arrayFlags
+	where  *string
+	period dateRangeFlags
+}
+
+// entryFilter selects entries, see entryFilters.
+type entryFilter struct {
+	tags       []string
+	expression tagExpression
+	period     dateRange
+}
+
+type contentMatch struct {
+	line    int
+	text    string
+	matches [][]int
+}
+
 // NewFindCommand creates a new command runner for finding entries
-func NewFindCommand(config Configuration, consoleWriter *os.File) *FindCommand {
+func NewFindCommand(config Configuration, store EntryStore, consoleWriter *os.File) *FindCommand {
 	findCommand := FindCommand{
 		options:       config,
 		flags:         flag.NewFlagSet("find", flag.ExitOnError),
+		store:         store,
 		consoleWriter: consoleWriter,
 	}
 	return &findCommand
 }
 
-// Run the list-tags command
+// Run the find command
 func (f *FindCommand) Run(ctx context.Context, subcommandArgs []string) error {
-	var tags arrayFlags
-	f.flags.Var(&tags, "tag", "Find entries of a specific tag or tags.")
+	filters := newEntryFilters(f.flags, "Find")
+	query := f.flags.String("q", "", "Find entries whose content contains the text.")
+	pattern := f.flags.String("regex", "", "Find entries whose content matches the regular expression.")
+	ignoreCase := f.flags.Bool("i", false, "Match -q and -regex case-insensitively.")
+	format := newFormatFlag(f.flags)
 	if !f.flags.Parsed() {
 		if err := f.flags.Parse(subcommandArgs); err != nil {
 			return err
 		}
 	}
-	index, err := tagMap(f.options.JournalPath)
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+	matcher, err := contentMatcher(*query, *pattern, *ignoreCase)
 	if err != nil {
 		return err
 	}
-	seen := make(map[string]bool)
-	for _, tag := range tags {
-		if index[tag] != nil {
-			for _, entry := range index[tag] {
-				if !seen[entry] {
-					seen[entry] = true
-				}
-			}
-		}
+	filter, err := filters.parse(ctx, f.options)
+	if err != nil {
+		return err
+	}
+	j, err := openJournal(f.options, f.store)
+	if err != nil {
+		return err
+	}
+	entries, err := j.entries()
+	if err != nil {
+		return err
+	}
+	if matcher == nil && !filter.isSet() {
+		entries = nil
 	}
-	output := make([]string, 0)
-	for key := range seen {
-		output = append(output, fmt.Sprintf("%s/entries/%s.md", f.options.JournalPath, key))
+	found, matches := findEntries(entries, filter, matcher)
+	if *format != formatText {
+		return f.writeEntryRecords(found, matches, *format)
+	}
+	if matcher == nil {
+		return f.printEntries(found)
+	}
+	return f.printContentMatches(found, matches)
+}
+
+func (f *FindCommand) writeEntryRecords(entries []*entryHeader, matches [][]contentMatch, format string) error {
+	records := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		records[i] = newEntryRecord(entry, matches[i])
+	}
+	return writeRecords(f.consoleWriter, format, records)
+}
+
+func (f *FindCommand) printEntries(entries []*entryHeader) error {
+	output := make([]string, len(entries))
+	for i, entry := range entries {
+		output[i] = entry.Filepath
 	}
 	sort.Strings(output)
 	fmt.Fprintln(f.consoleWriter, strings.Join(output, "\n"))
 	return nil
 }
+
+func (f *FindCommand) printContentMatches(entries []*entryHeader, matches [][]contentMatch) error {
+	highlight := isTerminal(f.consoleWriter)
+	for i, entry := range entries {
+		for _, match := range matches[i] {
+			fmt.Fprintf(f.consoleWriter, "%s:%d: %s\n", entry.Filepath, match.line, match.snippet(highlight))
+		}
+	}
+	return nil
+}
+
+func newEntryFilters(flags *flag.FlagSet, verb string) *entryFilters {
+	filters := entryFilters{period: newDateRangeFlags(flags)}
+	flags.Var(&filters.tags, "tag", fmt.Sprintf("%s entries of a specific tag or tags.", verb))
+	filters.where = flags.String("where", "", fmt.Sprintf("%s entries matching a tag expression, ie: 'incident and db and not resolved'.", verb))
+	return &filters
+}
+
+func (e *entryFilters) parse(ctx context.Context, config Configuration) (entryFilter, error) {
+	filter := entryFilter{tags: e.tags}
+	var err error
+	if filter.period, err = e.period.parse(ctx, config); err != nil {
+		return filter, err
+	}
+	if *e.where != "" {
+		filter.expression, err = parseTagExpression(*e.where)
+	}
+	return filter, err
+}
+
+func (f entryFilter) isSet() bool {
+	return len(f.tags) > 0 || f.expression != nil || f.period.isSet()
+}
+
+// apply returns the entries matching every filter.
+func (f entryFilter) apply(entries []*entryHeader) []*entryHeader {
+	selected := make([]*entryHeader, 0, len(entries))
+	for _, entry := range entries {
+		if len(f.tags) > 0 && !hasAnyTag(entry, f.tags) {
+			continue
+		}
+		if f.expression != nil && !f.expression.match(tagSet(entry.Tags())) {
+			continue
+		}
+		if !f.period.includes(entry) {
+			continue
+		}
+		selected = append(selected, entry)
+	}
+	return selected
+}
+
+// findEntries returns the entries selected by the filter whose content
+// matches, with the matching lines. Every selected entry is returned when
+// there is no matcher.
+func findEntries(entries []*entryHeader, filter entryFilter, matcher *regexp.Regexp) ([]*entryHeader, [][]contentMatch) {
+	var found []*entryHeader
+	var matches [][]contentMatch
+	for _, entry := range filter.apply(entries) {
+		var entryMatches []contentMatch
+		if matcher != nil {
+			if entryMatches = searchContent(entry, matcher); len(entryMatches) == 0 {
+				continue
+			}
+		}
+		found = append(found, entry)
+		matches = append(matches, entryMatches)
+	}
+	return found, matches
+}
+
+func contentMatcher(query, pattern string, ignoreCase bool) (*regexp.Regexp, error) {
+	if query != "" && pattern != "" {
+		return nil, errors.New("-q and -regex can not be used together")
+	}
+	expression := pattern
+	if query != "" {
+		expression = regexp.QuoteMeta(query)
+	}
+	if expression == "" {
+		return nil, nil
+	}
+	if ignoreCase {
+		expression = "(?i)" + expression
+	}
+	return regexp.Compile(expression)
+}
+
+func hasAnyTag(entry *entryHeader, tags []string) bool {
+	for _, entryTag := range entry.Tags() {
+		for _, tag := range tags {
+			if entryTag == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func searchContent(entry *entryHeader, matcher *regexp.Regexp) []contentMatch {
+	var matches []contentMatch
+	for i, line := range strings.Split(entry.Content, "\n") {
+		if locations := matcher.FindAllStringIndex(line, -1); locations != nil {
+			matches = append(matches, contentMatch{
+				line:    entry.contentLine + i,
+				text:    line,
+				matches: locations,
+			})
+		}
+	}
+	return matches
+}
+
+// snippet trims the matched line to a window around the first match and
+// optionally wraps every match in terminal highlighting.
+func (c contentMatch) snippet(highlight bool) string {
+	start, end := 0, len(c.text)
+	if end-start > snippetWidth {
+		start = c.matches[0][0] - snippetWidth/4
+		if start < 0 {
+			start = 0
+		}
+		if start+snippetWidth < end {
+			end = start + snippetWidth
+		}
+		for start > 0 && !utf8.RuneStart(c.text[start]) {
+			start--
+		}
+		for end < len(c.text) && !utf8.RuneStart(c.text[end]) {
+			end++
+		}
+	}
+	var output strings.Builder
+	if start > 0 {
+		output.WriteString("...")
+	}
+	cursor := start
+	for _, location := range c.matches {
+		if location[0] < cursor || location[1] > end {
+			continue
+		}
+		output.WriteString(c.text[cursor:location[0]])
+		if highlight {
+			output.WriteString(highlightStart + c.text[location[0]:location[1]] + highlightEnd)
+		} else {
+			output.WriteString(c.text[location[0]:location[1]])
+		}
+		cursor = location[1]
+	}
+	output.WriteString(c.text[cursor:end])
+	if end < len(c.text) {
+		output.WriteString("...")
+	}
+	return strings.TrimSpace(output.String())
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,7 +19,7 @@ func TestFindTag(t *testing.T) {
 		JournalPath: path,
 	}
 	r, w, _ := os.Pipe()
-	cmd := commands.NewFindCommand(config, w)
+	cmd := commands.NewFindCommand(config, commands.NewFileStore(path), w)
 	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 1, 0, 0, 0, 0, time.UTC))
 	cmd.Run(ctx, []string{"-tag", "foo"})
 	w.Close()
@@ -29,3 +30,164 @@ func TestFindTag(t *testing.T) {
 	}
 	fmt.Println(string(output))
 }
+
+func TestFindContent(t *testing.T) {
+	path, _ := filepath.Abs("../fixtures")
+	config := commands.Configuration{
+		JournalPath: path,
+	}
+	inputs := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"query", []string{"-q", "Some"}, fmt.Sprintf("%v/entries/2018-08-01.md:7: Some Content\n", path)},
+		{"case sensitive", []string{"-q", "some"}, ""},
+		{"case insensitive", []string{"-q", "some", "-i"}, fmt.Sprintf("%v/entries/2018-08-01.md:7: Some Content\n", path)},
+		{"regex", []string{"-regex", "C[a-z]+t$"}, fmt.Sprintf("%v/entries/2018-08-01.md:7: Some Content\n", path)},
+		{"tag filtered", []string{"-q", "Some", "-tag", "baz"}, ""},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			r, w, _ := os.Pipe()
+			cmd := commands.NewFindCommand(config, commands.NewFileStore(path), w)
+			ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 1, 0, 0, 0, 0, time.UTC))
+			if err := cmd.Run(ctx, input.args); err != nil {
+				t.Fatal(err)
+			}
+			w.Close()
+			output, _ := ioutil.ReadAll(r)
+			if input.expected != string(output) {
+				t.Errorf("Expected %v, got %v", input.expected, string(output))
+			}
+		})
+	}
+}
+
+func TestFindWhere(t *testing.T) {
+	path, _ := filepath.Abs("../fixtures")
+	config := commands.Configuration{
+		JournalPath: path,
+	}
+	entry := func(name string) string {
+		return fmt.Sprintf("%v/entries/%v.md", path, name)
+	}
+	inputs := []struct {
+		where        string
+		expected     []string
+		expectsError bool
+	}{
+		{"incident", []string{entry("2018-08-02"), entry("2018-08-03"), entry("2018-08-04")}, false},
+		{"incident and db", []string{entry("2018-08-02"), entry("2018-08-03")}, false},
+		{"incident and db and not resolved", []string{entry("2018-08-02")}, false},
+		{"foo or resolved", []string{entry("2018-08-01"), entry("2018-08-03")}, false},
+		{"not incident", []string{entry("2018-08-01"), entry("2018-08-05")}, false},
+		{"NOT (db OR foo)", []string{entry("2018-08-04")}, false},
+		{"incident and not (db or \"resolved\")", []string{entry("2018-08-04")}, false},
+		{"'and'", []string{}, false},
+		{"incident and", nil, true},
+		{"(incident or db", nil, true},
+		{"incident db", nil, true},
+		{"\"incident", nil, true},
+	}
+	for _, input := range inputs {
+		t.Run(input.where, func(t *testing.T) {
+			r, w, _ := os.Pipe()
+			cmd := commands.NewFindCommand(config, commands.NewFileStore(path), w)
+			ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 1, 0, 0, 0, 0, time.UTC))
+			err := cmd.Run(ctx, []string{"-where", input.where})
+			w.Close()
+			if err != nil && !input.expectsError {
+				t.Fatal(err)
+			} else if err == nil && input.expectsError {
+				t.Fatal("Expected input to produce an error")
+			} else if err != nil && input.expectsError {
+				return
+			}
+			output, _ := ioutil.ReadAll(r)
+			expectedOutput := strings.Join(input.expected, "\n") + "\n"
+			if expectedOutput != string(output) {
+				t.Errorf("Expected %v, got %v", expectedOutput, string(output))
+			}
+		})
+	}
+}
+
+func TestFindDateRange(t *testing.T) {
+	path, _ := filepath.Abs("../fixtures")
+	config := commands.Configuration{
+		JournalPath: path,
+	}
+	entry := func(name string) string {
+		return fmt.Sprintf("%v/entries/%v.md", path, name)
+	}
+	inputs := []struct {
+		name         string
+		args         []string
+		expected     []string
+		expectsError bool
+	}{
+		{"since date", []string{"-since", "2018-08-03"}, []string{entry("2018-08-03"), entry("2018-08-04"), entry("2018-08-05")}, false},
+		{"until date", []string{"-until", "2018-08-02"}, []string{entry("2018-08-01"), entry("2018-08-02")}, false},
+		{"between dates", []string{"-since", "2018-08-02", "-until", "2018-08-03"}, []string{entry("2018-08-02"), entry("2018-08-03")}, false},
+		{"relative days", []string{"-since", "2d"}, []string{entry("2018-08-04"), entry("2018-08-05")}, false},
+		{"filename fallback", []string{"-since", "yesterday"}, []string{entry("2018-08-05")}, false},
+		{"this month", []string{"-since", "this-month", "-tag", "db"}, []string{entry("2018-08-02"), entry("2018-08-03"), entry("2018-08-05")}, false},
+		{"last month", []string{"-since", "last-month", "-until", "last-month"}, []string{}, false},
+		{"invalid", []string{"-since", "soon"}, nil, true},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			r, w, _ := os.Pipe()
+			cmd := commands.NewFindCommand(config, commands.NewFileStore(path), w)
+			ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 6, 10, 0, 0, 0, time.UTC))
+			err := cmd.Run(ctx, input.args)
+			w.Close()
+			if err != nil && !input.expectsError {
+				t.Fatal(err)
+			} else if err == nil && input.expectsError {
+				t.Fatal("Expected input to produce an error")
+			} else if err != nil && input.expectsError {
+				return
+			}
+			output, _ := ioutil.ReadAll(r)
+			expectedOutput := strings.Join(input.expected, "\n") + "\n"
+			if expectedOutput != string(output) {
+				t.Errorf("Expected %v, got %v", expectedOutput, string(output))
+			}
+		})
+	}
+}
+
+func TestFindFormat(t *testing.T) {
+	path, _ := filepath.Abs("../fixtures")
+	config := commands.Configuration{
+		JournalPath: path,
+	}
+	inputs := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"jsonl", []string{"-tag", "foo", "-format", "jsonl"}, fmt.Sprintf(`{"path":"%v/entries/2018-08-01.md","filename":"2018-08-01.md","subject":"2018-08-01","date":"2018-08-01T00:00:00Z","tags":["foo","bar"],"word_count":2}
+`, path)},
+		{"jsonl without date", []string{"-q", "Vacuumed", "-format", "jsonl"}, fmt.Sprintf(`{"path":"%v/entries/2018-08-05.md","filename":"2018-08-05.md","subject":"2018-08-05","tags":["db"],"word_count":4,"matches":[{"line":5,"text":"Vacuumed the reporting database."}]}
+`, path)},
+		{"json empty", []string{"-tag", "missing", "-format", "json"}, "[]\n"},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			r, w, _ := os.Pipe()
+			cmd := commands.NewFindCommand(config, commands.NewFileStore(path), w)
+			ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 1, 0, 0, 0, 0, time.UTC))
+			if err := cmd.Run(ctx, input.args); err != nil {
+				t.Fatal(err)
+			}
+			w.Close()
+			output, _ := ioutil.ReadAll(r)
+			if input.expected != string(output) {
+				t.Errorf("Expected %v, got %v", input.expected, string(output))
+			}
+		})
+	}
+}
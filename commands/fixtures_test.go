@@ -0,0 +1,38 @@
+package commands_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+// fixtureStore loads the fixture entries into memory so tests can modify
+// them freely.
+func fixtureStore(t *testing.T) *commands.MemoryStore {
+	store := commands.NewMemoryStore()
+	files, err := filepath.Glob("../fixtures/entries/*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Write(strings.TrimSuffix(filepath.Base(file), ".md"), content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func readEntry(t *testing.T, store commands.EntryStore, name string) string {
+	content, err := store.Read(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
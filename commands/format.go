@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	formatText  = "text"
+	formatJSON  = "json"
+	formatJSONL = "jsonl"
+)
+
+type entryRecord struct {
+	Path      string        `json:"path"`
+	Filename  string        `json:"filename"`
+	Subject   string        `json:"subject"`
+	Date      string        `json:"date,omitempty"`
+	Tags      []string      `json:"tags"`
+	WordCount int           `json:"word_count"`
+	Matches   []matchRecord `json:"matches,omitempty"`
+}
+
+type matchRecord struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+type tagRecord struct {
+	Tag      string   `json:"tag"`
+	Count    int      `json:"count"`
+	LastUsed string   `json:"last_used,omitempty"`
+	Entries  []string `json:"entries"`
+}
+
+func newFormatFlag(flags *flag.FlagSet) *string {
+	return flags.String("format", formatText, "Output format: text, json or jsonl.")
+}
+
+func validateFormat(format string) error {
+	switch format {
+	case formatText, formatJSON, formatJSONL:
+		return nil
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+func newEntryRecord(entry *entryHeader, matches []contentMatch) entryRecord {
+	record := entryRecord{
+		Path:      entry.Filepath,
+		Filename:  entry.Filename,
+		Subject:   strings.TrimSuffix(entry.Filename, ".md"),
+		Tags:      entry.Tags(),
+		WordCount: len(strings.Fields(entry.Content)),
+	}
+	if record.Tags == nil {
+		record.Tags = []string{}
+	}
+	if !entry.Date().IsZero() {
+		record.Date = entry.Date().Format(time.RFC3339)
+	}
+	for _, match := range matches {
+		record.Matches = append(record.Matches, matchRecord{
+			Line: match.line,
+			Text: match.text,
+		})
+	}
+	return record
+}
+
+// writeRecords encodes a slice of records as a single JSON array or as one
+// JSON object per line.
+func writeRecords(writer io.Writer, format string, records []interface{}) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	if format == formatJSON {
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	}
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GitCommandRunner runs git operations on the repository of a journal.
+type GitCommandRunner interface {
+	Pull(path string) error
+	Fetch(path string) error
+	Add(path string, files ...string) error
+	Commit(path string, message string) error
+	Push(path string, remote string, branch string) error
+	Status(path string) (GitStatus, error)
+	// Show returns a file as of a revision, or a merge stage such as ":2"
+	// for our side of a conflict and ":3" for theirs.
+	Show(path string, revision string, file string) ([]byte, error)
+	// Log returns the commits that changed a file, newest first.
+	Log(path string, file string) ([]GitCommit, error)
+}
+
+// Merge stages of a conflicted file, for GitCommandRunner.Show.
+const (
+	StageOurs   = ":2"
+	StageTheirs = ":3"
+)
+
+// Reasons a git operation failed, see GitError.
+var (
+	ErrNotRepository   = errors.New("journal is not a git repository")
+	ErrNothingToCommit = errors.New("nothing to commit")
+	ErrAuthentication  = errors.New("authentication with the remote failed")
+	ErrNonFastForward  = errors.New("remote has changes the journal does not, run sync first")
+	ErrDetachedHead    = errors.New("journal is not on a branch")
+	ErrConflict        = errors.New("journal has conflicting changes")
+	ErrLocalChanges    = errors.New("journal has changes that are not memorized")
+)
+
+// GitError is returned when a git operation fails. Reason is one of the Err
+// values above when the failure was recognized.
+type GitError struct {
+	Args   []string
+	Output string
+	Reason error
+}
+
+func (e *GitError) Error() string {
+	message := "git " + strings.Join(e.Args, " ") + " failed"
+	if e.Reason != nil {
+		message = e.Reason.Error()
+	}
+	// The last line of git's output is usually the fatal error.
+	if output := strings.TrimSpace(e.Output); output != "" {
+		lines := strings.Split(output, "\n")
+		message += ": " + strings.TrimSpace(lines[len(lines)-1])
+	}
+	return message
+}
+
+// gitErrorIs reports whether the error is, or is a GitError caused by, the reason.
+func gitErrorIs(err error, reason error) bool {
+	if gitErr, ok := err.(*GitError); ok {
+		return gitErr.Reason == reason
+	}
+	return err == reason
+}
+
+// GitStatus is the state of the working tree and branch of a repository.
+type GitStatus struct {
+	// Branch is empty when HEAD is detached.
+	Branch   string
+	Upstream string
+	Ahead    int
+	Behind   int
+	Files    []GitFileStatus
+}
+
+// GitFileStatus is a changed file in the working tree.
+type GitFileStatus struct {
+	Path string
+	// Code is the two letter status of git status --short, ie: "??" for an
+	// untracked file or ".M" for a file modified in the working tree.
+	Code string
+}
+
+// GitCommit is a commit that changed a file, with the lines it added and
+// deleted in that file.
+type GitCommit struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Subject string
+	Added   int
+	Deleted int
+}
+
+// GitCommandRunnerImpl runs operations with the git command line, so the
+// user's credentials, ssh configuration and merge settings all apply.
+type GitCommandRunnerImpl struct{}
+
+var gitErrorPatterns = []struct {
+	pattern string
+	reason  error
+}{
+	{"not a git repository", ErrNotRepository},
+	{"nothing to commit", ErrNothingToCommit},
+	{"nothing added to commit", ErrNothingToCommit},
+	{"Authentication failed", ErrAuthentication},
+	{"Permission denied", ErrAuthentication},
+	{"could not read Username", ErrAuthentication},
+	{"non-fast-forward", ErrNonFastForward},
+	{"fetch first", ErrNonFastForward},
+	{"not currently on a branch", ErrDetachedHead},
+	{"HEAD detached", ErrDetachedHead},
+	{"CONFLICT", ErrConflict},
+	{"would be overwritten", ErrLocalChanges},
+}
+
+// lockedBuffer collects the standard output and error of a command, which
+// are written concurrently, in the order they are printed.
+type lockedBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
+
+// run runs git in the repository, returning its standard output. Failures
+// are classified from everything git printed, as some reasons such as
+// "nothing to commit" are printed on the standard output.
+func (g GitCommandRunnerImpl) run(path string, params ...string) (string, error) {
+	args := append([]string{"-C", path}, params...)
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	var stdout bytes.Buffer
+	output := &lockedBuffer{}
+	cmd.Stdout = io.MultiWriter(&stdout, output)
+	cmd.Stderr = output
+	if err := cmd.Run(); err != nil {
+		gitErr := &GitError{Args: params, Output: output.String()}
+		for _, known := range gitErrorPatterns {
+			if strings.Contains(gitErr.Output, known.pattern) {
+				gitErr.Reason = known.reason
+				break
+			}
+		}
+		if _, ok := err.(*exec.ExitError); !ok && gitErr.Output == "" {
+			gitErr.Output = err.Error()
+		}
+		return stdout.String(), gitErr
+	}
+	return stdout.String(), nil
+}
+
+func (g GitCommandRunnerImpl) Pull(path string) error {
+	_, err := g.run(path, "pull", "--no-rebase", "--no-edit")
+	return err
+}
+
+func (g GitCommandRunnerImpl) Fetch(path string) error {
+	_, err := g.run(path, "fetch", "--quiet")
+	return err
+}
+
+func (g GitCommandRunnerImpl) Add(path string, files ...string) error {
+	_, err := g.run(path, append([]string{"add", "--"}, files...)...)
+	return err
+}
+
+func (g GitCommandRunnerImpl) Commit(path string, message string) error {
+	_, err := g.run(path, "commit", "-m", message)
+	return err
+}
+
+func (g GitCommandRunnerImpl) Push(path string, remote string, branch string) error {
+	if _, err := g.run(path, "symbolic-ref", "-q", "HEAD"); err != nil {
+		return &GitError{Args: []string{"push", remote, branch}, Reason: ErrDetachedHead}
+	}
+	_, err := g.run(path, "push", remote, "HEAD:"+branch)
+	return err
+}
+
+func (g GitCommandRunnerImpl) Status(path string) (GitStatus, error) {
+	output, err := g.run(path, "status", "--porcelain=v2", "--branch", "--untracked-files=all", "-z")
+	if err != nil {
+		return GitStatus{}, err
+	}
+	return parseGitStatus(output), nil
+}
+
+func (g GitCommandRunnerImpl) Show(path string, revision string, file string) ([]byte, error) {
+	output, err := g.run(path, "show", revision+":"+file)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(output), nil
+}
+
+func (g GitCommandRunnerImpl) Log(path string, file string) ([]GitCommit, error) {
+	output, err := g.run(path, "log", "--follow", "--numstat", "--format=%x1e%H%x1f%an%x1f%aI%x1f%s", "--", file)
+	if err != nil {
+		return nil, err
+	}
+	return parseGitLog(output)
+}
+
+// Unmerged returns the files left conflicted by a merge.
+func (s GitStatus) Unmerged() []GitFileStatus {
+	var files []GitFileStatus
+	for _, file := range s.Files {
+		switch file.Code {
+		case "UU", "AA", "DD", "AU", "UA", "DU", "UD":
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// parseGitStatus parses the output of git status --porcelain=v2 --branch -z.
+func parseGitStatus(output string) GitStatus {
+	var status GitStatus
+	records := strings.Split(output, "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		fields := strings.Fields(record)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "#":
+			switch fields[1] {
+			case "branch.head":
+				if len(fields) > 2 && fields[2] != "(detached)" {
+					status.Branch = fields[2]
+				}
+			case "branch.upstream":
+				if len(fields) > 2 {
+					status.Upstream = fields[2]
+				}
+			case "branch.ab":
+				if len(fields) > 3 {
+					status.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[2], "+"))
+					status.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[3], "-"))
+				}
+			}
+		case "1", "2", "u":
+			// Ordinary, renamed and unmerged entries have 8, 9 and 10 fields
+			// before the path, which may itself contain spaces.
+			pathField := map[string]int{"1": 8, "2": 9, "u": 10}[fields[0]]
+			parts := strings.SplitN(record, " ", pathField+1)
+			if len(parts) <= pathField {
+				continue
+			}
+			status.Files = append(status.Files, GitFileStatus{Path: parts[pathField], Code: fields[1]})
+			if fields[0] == "2" {
+				// Renamed entries are followed by the original path.
+				i++
+			}
+		case "?":
+			status.Files = append(status.Files, GitFileStatus{Path: record[2:], Code: "??"})
+		}
+	}
+	return status
+}
+
+// parseGitLog parses the output of git log --numstat with records separated
+// by \x1e and fields by \x1f.
+func parseGitLog(output string) ([]GitCommit, error) {
+	var commits []GitCommit
+	for _, record := range strings.Split(output, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		fields := strings.SplitN(lines[0], "\x1f", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return nil, err
+		}
+		commit := GitCommit{Hash: fields[0], Author: fields[1], Date: date, Subject: fields[3]}
+		for _, line := range lines[1:] {
+			// Binary files are listed with "-" instead of line counts.
+			stats := strings.Fields(line)
+			if len(stats) < 2 {
+				continue
+			}
+			added, _ := strconv.Atoi(stats[0])
+			deleted, _ := strconv.Atoi(stats[1])
+			commit.Added += added
+			commit.Deleted += deleted
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
@@ -0,0 +1,229 @@
+package commands_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func runGit(t *testing.T, path string, args ...string) {
+	cmd := exec.Command("git", append([]string{"-C", path}, args...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+}
+
+// gitJournals creates a bare remote and two clones of it on the master branch.
+func gitJournals(t *testing.T) (string, string, func()) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	directory, err := ioutil.TempDir("", "jrnl-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restoreEnv := setEnv(t, map[string]string{
+		"GIT_AUTHOR_NAME":     "jrnl",
+		"GIT_AUTHOR_EMAIL":    "jrnl@example.com",
+		"GIT_COMMITTER_NAME":  "jrnl",
+		"GIT_COMMITTER_EMAIL": "jrnl@example.com",
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"HOME":                directory,
+	})
+	remote := filepath.Join(directory, "remote.git")
+	laptop := filepath.Join(directory, "laptop")
+	desktop := filepath.Join(directory, "desktop")
+	runGit(t, directory, "init", "-q", "--bare", remote)
+	runGit(t, remote, "symbolic-ref", "HEAD", "refs/heads/master")
+	runGit(t, directory, "clone", "-q", remote, laptop)
+	runGit(t, laptop, "symbolic-ref", "HEAD", "refs/heads/master")
+	runGit(t, laptop, "commit", "-q", "--allow-empty", "-m", "Initial")
+	runGit(t, laptop, "push", "-q", "-u", "origin", "master")
+	runGit(t, directory, "clone", "-q", remote, desktop)
+	return laptop, desktop, func() {
+		restoreEnv()
+		os.RemoveAll(directory)
+	}
+}
+
+func writeJournalFile(t *testing.T, path string, name string, content string) {
+	os.MkdirAll(filepath.Dir(filepath.Join(path, name)), os.ModePerm)
+	if err := ioutil.WriteFile(filepath.Join(path, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGitCommandRunner(t *testing.T) {
+	laptop, desktop, cleanup := gitJournals(t)
+	defer cleanup()
+	runner := commands.GitCommandRunnerImpl{}
+
+	if err := runner.Commit(laptop, "Memorized journal"); !isGitError(err, commands.ErrNothingToCommit) {
+		t.Errorf("Expected nothing to commit, got %v", err)
+	}
+	writeJournalFile(t, laptop, "entries/2018-08-01.md", "Some Content")
+	writeJournalFile(t, laptop, "entries/my entry.md", "With a space")
+	status, err := runner.Status(laptop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := commands.GitStatus{
+		Branch:   "master",
+		Upstream: "origin/master",
+		Files: []commands.GitFileStatus{
+			{Path: "entries/2018-08-01.md", Code: "??"},
+			{Path: "entries/my entry.md", Code: "??"},
+		},
+	}
+	if !reflect.DeepEqual(status, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, status)
+	}
+	if err := runner.Add(laptop, "."); err != nil {
+		t.Fatal(err)
+	}
+	if status, err = runner.Status(laptop); err != nil {
+		t.Fatal(err)
+	}
+	expected.Files = []commands.GitFileStatus{
+		{Path: "entries/2018-08-01.md", Code: "A."},
+		{Path: "entries/my entry.md", Code: "A."},
+	}
+	if !reflect.DeepEqual(status, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, status)
+	}
+	if err := runner.Commit(laptop, "Memorized journal"); err != nil {
+		t.Fatal(err)
+	}
+	if status, err = runner.Status(laptop); err != nil {
+		t.Fatal(err)
+	}
+	if status.Ahead != 1 || status.Behind != 0 || len(status.Files) != 0 {
+		t.Errorf("Expected a clean tree one commit ahead, got %+v", status)
+	}
+	if err := runner.Push(laptop, "origin", "master"); err != nil {
+		t.Fatal(err)
+	}
+
+	writeJournalFile(t, desktop, "entries/2018-08-02.md", "Desktop")
+	runner.Add(desktop, ".")
+	runner.Commit(desktop, "Memorized journal")
+	if err := runner.Push(desktop, "origin", "master"); !isGitError(err, commands.ErrNonFastForward) {
+		t.Errorf("Expected a non-fast-forward push, got %v", err)
+	}
+	if err := runner.Pull(desktop); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.Push(desktop, "origin", "master"); err != nil {
+		t.Fatal(err)
+	}
+
+	runGit(t, laptop, "checkout", "-q", "--detach")
+	if err := runner.Push(laptop, "origin", "master"); !isGitError(err, commands.ErrDetachedHead) {
+		t.Errorf("Expected a detached HEAD, got %v", err)
+	}
+	if status, err = runner.Status(laptop); err != nil || status.Branch != "" {
+		t.Errorf("Expected no branch, got %+v %v", status, err)
+	}
+	if _, err := runner.Status(filepath.Dir(laptop)); !isGitError(err, commands.ErrNotRepository) {
+		t.Errorf("Expected not a repository, got %v", err)
+	}
+	if content, err := runner.Show(laptop, "HEAD", "entries/my entry.md"); err != nil || string(content) != "With a space" {
+		t.Errorf("Expected the committed entry, got %q %v", content, err)
+	}
+	if _, err := runner.Show(filepath.Dir(laptop), "HEAD", "entries/my entry.md"); !isGitError(err, commands.ErrNotRepository) {
+		t.Errorf("Expected not a repository, got %v", err)
+	}
+}
+
+func isGitError(err error, reason error) bool {
+	gitErr, ok := err.(*commands.GitError)
+	return ok && gitErr.Reason == reason
+}
+
+func TestSyncWithGit(t *testing.T) {
+	laptop, desktop, cleanup := gitJournals(t)
+	defer cleanup()
+	runner := commands.GitCommandRunnerImpl{}
+	base := "---\ntags:\n- db\n---\n- 09:00 Standup\n"
+	writeJournalFile(t, laptop, "entries/2018-08-07.md", base)
+	runner.Add(laptop, ".")
+	runner.Commit(laptop, "Base")
+	runner.Push(laptop, "origin", "master")
+	runGit(t, desktop, "pull", "-q")
+	writeJournalFile(t, desktop, "entries/2018-08-07.md", base+"- 11:00 Rolled back\n")
+	runner.Add(desktop, ".")
+	runner.Commit(desktop, "Desktop")
+	if err := runner.Push(desktop, "origin", "master"); err != nil {
+		t.Fatal(err)
+	}
+	writeJournalFile(t, laptop, "entries/2018-08-07.md", "---\ntags:\n- deploy\n---\n- 09:00 Standup\n- 10:00 Deployed\n")
+	runner.Add(laptop, ".")
+	runner.Commit(laptop, "Laptop")
+
+	cmd := commands.NewSyncCommand(commands.Configuration{JournalPath: laptop}, commands.NewFileStore(laptop), runner, os.Stdout)
+	if err := cmd.Run(context.Background(), []string{}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(laptop, "entries/2018-08-07.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "---\ntags:\n- db\n- deploy\n---\n- 09:00 Standup\n- 10:00 Deployed\n\n---\n\n*Merged by jrnl sync: the other copy of this entry continues below.*\n\n- 11:00 Rolled back\n"
+	if string(content) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(content))
+	}
+	status, err := runner.Status(laptop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Files) != 0 || status.Ahead != 2 || status.Behind != 0 {
+		t.Errorf("Expected the merge to be committed, got %+v", status)
+	}
+}
+
+func TestHistoryWithGit(t *testing.T) {
+	laptop, _, cleanup := gitJournals(t)
+	defer cleanup()
+	runner := commands.GitCommandRunnerImpl{}
+	writeJournalFile(t, laptop, "entries/2018-08-07.md", "---\ntags:\n- db\n---\n- 09:00 Standup\n")
+	runner.Add(laptop, ".")
+	runner.Commit(laptop, "First")
+	writeJournalFile(t, laptop, "entries/2018-08-07.md", "---\ntags:\n- db\n---\n- 09:00 Standup\n- 10:00 Deployed\n- 11:00 Rolled back\n")
+	writeJournalFile(t, laptop, "entries/2018-08-08.md", "Unrelated")
+	runner.Add(laptop, ".")
+	runner.Commit(laptop, "Second")
+
+	commits, err := runner.Log(laptop, "entries/2018-08-07.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("Expected 2 commits, got %+v", commits)
+	}
+	if commits[0].Subject != "Second" || commits[0].Author != "jrnl" || commits[0].Added != 2 || commits[0].Deleted != 0 {
+		t.Errorf("Expected the second commit to add 2 lines, got %+v", commits[0])
+	}
+	if commits[1].Subject != "First" || commits[1].Added != 5 || commits[1].Date.IsZero() {
+		t.Errorf("Expected the first commit to add 5 lines, got %+v", commits[1])
+	}
+
+	r, w, _ := os.Pipe()
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 0, 0, 0, 0, time.UTC))
+	cmd := commands.NewDiffCommand(commands.Configuration{JournalPath: laptop}, commands.NewFileStore(laptop), runner, w)
+	if err := cmd.Run(ctx, []string{"HEAD~1", "HEAD"}); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	output, _ := ioutil.ReadAll(r)
+	expected := "--- HEAD~1:entries/2018-08-07.md\n+++ HEAD:entries/2018-08-07.md\n@@ -3,3 +3,5 @@\n - db\n ---\n - 09:00 Standup\n+- 10:00 Deployed\n+- 11:00 Rolled back\n"
+	if string(output) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(output))
+	}
+}
@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+type HistoryCommand struct {
+	options       Configuration
+	flags         *flag.FlagSet
+	store         EntryStore
+	runner        GitCommandRunner
+	consoleWriter *os.File
+}
+
+// entryFlags select a single entry by subject or date, like open does. The
+// entry of the current date is selected by default.
+type entryFlags struct {
+	subject *string
+	date    *string
+}
+
+// NewHistoryCommand creates a new command runner for listing the commits of an entry
+func NewHistoryCommand(config Configuration, store EntryStore, runner GitCommandRunner, consoleWriter *os.File) *HistoryCommand {
+	historyCommand := HistoryCommand{
+		options:       config,
+		flags:         flag.NewFlagSet("history", flag.ExitOnError),
+		store:         store,
+		runner:        runner,
+		consoleWriter: consoleWriter,
+	}
+	return &historyCommand
+}
+
+// Run the history command
+func (h *HistoryCommand) Run(ctx context.Context, subcommandArgs []string) error {
+	selected := newEntryFlags(h.flags)
+	if !h.flags.Parsed() {
+		if err := h.flags.Parse(subcommandArgs); err != nil {
+			return err
+		}
+	}
+	name, err := selected.name(ctx, h.options)
+	if err != nil {
+		return err
+	}
+	commits, err := h.runner.Log(h.options.JournalPath, entryRepositoryPath(name))
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("%s has not been memorized", h.store.Path(name))
+	}
+	for _, commit := range commits {
+		hash := commit.Hash
+		if len(hash) > 7 {
+			hash = hash[:7]
+		}
+		fmt.Fprintf(h.consoleWriter, "%s %s %s +%d -%d %s\n",
+			hash,
+			commit.Date.Format("2006-01-02 15:04"),
+			commit.Author,
+			commit.Added,
+			commit.Deleted,
+			commit.Subject)
+	}
+	return nil
+}
+
+func newEntryFlags(flags *flag.FlagSet) entryFlags {
+	return entryFlags{
+		subject: flags.String("s", "", "Subject of the entry."),
+		date:    flags.String("date", "", "Date of the entry (YYYY-MM-DD, today, yesterday, ...)."),
+	}
+}
+
+func (e entryFlags) name(ctx context.Context, config Configuration) (string, error) {
+	if *e.subject != "" {
+		return *e.subject, nil
+	}
+	date := ctx.Value(CommandContextKey("date")).(time.Time)
+	if *e.date != "" {
+		start, _, err := parseDateExpression(*e.date, date)
+		if err != nil {
+			return "", err
+		}
+		date = start
+	}
+	return config.entryName(date), nil
+}
+
+// entryRepositoryPath is the path of an entry in the journal repository.
+func entryRepositoryPath(name string) string {
+	return path.Join("entries", name+".md")
+}
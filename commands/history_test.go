@@ -0,0 +1,47 @@
+package commands_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func TestHistory(t *testing.T) {
+	runner := newFakeGitCommand()
+	runner.log["entries/2018-08-07.md"] = []commands.GitCommit{
+		{Hash: "9f2c4e1d0b", Author: "Jane", Date: time.Date(2018, time.August, 7, 17, 5, 0, 0, time.UTC), Subject: "Memorized journal: 1 modified", Added: 2, Deleted: 1},
+		{Hash: "3a7b", Author: "Jane", Date: time.Date(2018, time.August, 7, 9, 30, 0, 0, time.UTC), Subject: "Memorized journal: 1 added", Added: 6},
+	}
+	runner.log["entries/Release plan.md"] = runner.log["entries/2018-08-07.md"][1:]
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 0, 0, 0, 0, time.UTC))
+	inputs := []struct {
+		name         string
+		args         []string
+		expected     string
+		expectsError bool
+	}{
+		{"current entry", []string{}, "9f2c4e1 2018-08-07 17:05 Jane +2 -1 Memorized journal: 1 modified\n3a7b 2018-08-07 09:30 Jane +6 -0 Memorized journal: 1 added\n", false},
+		{"subject", []string{"-s", "Release plan"}, "3a7b 2018-08-07 09:30 Jane +6 -0 Memorized journal: 1 added\n", false},
+		{"date", []string{"-date", "2018-08-07"}, "9f2c4e1 2018-08-07 17:05 Jane +2 -1 Memorized journal: 1 modified\n3a7b 2018-08-07 09:30 Jane +6 -0 Memorized journal: 1 added\n", false},
+		{"never memorized", []string{"-date", "yesterday"}, "", true},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			r, w, _ := os.Pipe()
+			cmd := commands.NewHistoryCommand(commands.Configuration{JournalPath: "/some/path"}, fixtureStore(t), runner, w)
+			err := cmd.Run(ctx, input.args)
+			if input.expectsError != (err != nil) {
+				t.Errorf("Expected error %v, got %v", input.expectsError, err)
+			}
+			w.Close()
+			output, _ := ioutil.ReadAll(r)
+			if input.expected != string(output) {
+				t.Errorf("Expected %q, got %q", input.expected, string(output))
+			}
+		})
+	}
+}
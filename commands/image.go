@@ -16,13 +16,15 @@ var appendTemplate = "\n\n---\n\n![](bin/%s)\n"
 type ImageCommand struct {
 	options Configuration
 	flags   *flag.FlagSet
+	store   EntryStore
 }
 
 // NewImageCommand creates a new command runner for image command
-func NewImageCommand(config Configuration) *ImageCommand {
+func NewImageCommand(config Configuration, store EntryStore) *ImageCommand {
 	imageCommand := ImageCommand{
 		options: config,
 		flags:   flag.NewFlagSet("image", flag.ExitOnError),
+		store:   store,
 	}
 	return &imageCommand
 }
@@ -35,11 +37,11 @@ func (i *ImageCommand) Run(ctx context.Context, subcommandArgs []string) error {
 			return err
 		}
 	}
-	var filebase string
+	var name string
 	if *subjectFlag != "" {
-		filebase = *subjectFlag
+		name = *subjectFlag
 	} else {
-		filebase = ctx.Value(CommandContextKey("date")).(time.Time).Format("2006-01-02")
+		name = i.options.entryName(ctx.Value(CommandContextKey("date")).(time.Time))
 	}
 	commandArgs := i.flags.Args()
 	if len(commandArgs) == 0 {
@@ -49,19 +51,34 @@ func (i *ImageCommand) Run(ctx context.Context, subcommandArgs []string) error {
 	if err != nil {
 		return err
 	}
-	os.MkdirAll(i.options.JournalPath+"/bin", os.ModePerm)
-	err = ioutil.WriteFile(i.options.JournalPath+"/bin/"+filepath.Base(commandArgs[0]), data, 0644)
+	j, err := openJournal(i.options, i.store)
 	if err != nil {
 		return err
 	}
-	journalEntry := i.options.JournalPath + "/entries/" + filebase + ".md"
-	f, err := os.OpenFile(journalEntry, os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		f, err = os.Create(journalEntry)
+	return attachImage(j, name, filepath.Base(commandArgs[0]), data)
+}
+
+// attachImage stores an image and appends it to the end of an entry.
+func attachImage(j *journal, name string, filename string, data []byte) error {
+	if err := j.store.Attach(filename, data); err != nil {
+		return err
 	}
-	if err != nil {
+	image := fmt.Sprintf(appendTemplate, filename)
+	entry, err := j.read(name)
+	if os.IsNotExist(err) && j.cipher.mode != encryptionNone {
+		entry, err = j.newEntry(name), nil
+	}
+	if err == nil && (entry.locked || j.cipher.shouldEncrypt(entry)) {
+		entry.Content += image
+		return j.write(entry)
+	}
+	if err != nil && !os.IsNotExist(err) && j.cipher.mode != encryptionNone {
+		return err
+	}
+	// Plain entries are appended to as is.
+	document, err := j.store.Read(name)
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
-	_, err = f.WriteString(fmt.Sprintf(appendTemplate, filepath.Base(commandArgs[0])))
-	return err
+	return j.store.Write(name, append(document, image...))
 }
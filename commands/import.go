@@ -0,0 +1,394 @@
+package commands
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Formats of the journals import reads.
+const (
+	importJrnl     = "jrnl"
+	importDayOne   = "dayone"
+	importMarkdown = "markdown"
+)
+
+var (
+	// jrnlEntryPattern matches the first line of a jrnl.sh entry, ie:
+	// "[2018-08-06 09:30] Title" or "[2018-08-06 09:30:00 AM] Title", with or
+	// without the brackets.
+	jrnlEntryPattern = regexp.MustCompile(`^(?:\[(\d{4}-\d{2}-\d{2} [^\]]*)\]|(\d{4}-\d{2}-\d{2} \d{1,2}:\d{2}(?::\d{2})?(?: ?[AaPp][Mm]\b)?)) ?(.*)$`)
+	// jrnlTimeFormats are the time formats of jrnl.sh entries: the default
+	// "%F %r" and 24-hour clocks.
+	jrnlTimeFormats = []string{
+		"2006-01-02 15:04",
+		"2006-01-02 15:04:05",
+		"2006-01-02 3:04 PM",
+		"2006-01-02 3:04:05 PM",
+		"2006-01-02 3:04PM",
+		"2006-01-02 3:04:05PM",
+	}
+	// markdownTimeFormats are the dates found in the frontmatter of markdown
+	// notes, besides the date format of the journal.
+	markdownTimeFormats = []string{
+		"2006-01-02",
+		"2006-01-02 15:04",
+		"2006-01-02 15:04:05",
+		time.RFC3339,
+	}
+	jrnlTagPattern = regexp.MustCompile(`(?:^|\s)@([\w-]+)`)
+	datePattern    = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+	imagePattern   = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+)
+
+type ImportCommand struct {
+	options       Configuration
+	flags         *flag.FlagSet
+	store         EntryStore
+	consoleWriter *os.File
+}
+
+// importedEntry is an entry read from another journaling tool.
+type importedEntry struct {
+	// date is zero for entries without a date, which are named after their subject.
+	date    time.Time
+	subject string
+	text    string
+	tags    []string
+	// fields are the other frontmatter fields of the entry, ie: its title.
+	fields yaml.MapSlice
+	// timed entries are written with their time, like write -p does.
+	timed bool
+}
+
+// dayOneExport is the JSON file of a Day One export.
+type dayOneExport struct {
+	Entries []struct {
+		CreationDate time.Time `json:"creationDate"`
+		TimeZone     string    `json:"timeZone"`
+		Text         string    `json:"text"`
+		Tags         []string  `json:"tags"`
+		Photos       []struct {
+			Identifier string `json:"identifier"`
+			MD5        string `json:"md5"`
+			Type       string `json:"type"`
+		} `json:"photos"`
+	} `json:"entries"`
+}
+
+// NewImportCommand creates a new command runner for importing entries from other journaling tools
+func NewImportCommand(config Configuration, store EntryStore, consoleWriter *os.File) *ImportCommand {
+	importCommand := ImportCommand{
+		options:       config,
+		flags:         flag.NewFlagSet("import", flag.ExitOnError),
+		store:         store,
+		consoleWriter: consoleWriter,
+	}
+	return &importCommand
+}
+
+// Run the import command
+func (i *ImportCommand) Run(ctx context.Context, subcommandArgs []string) error {
+	format := i.flags.String("format", "", "Format of the imported journal: jrnl, dayone or markdown. Detected from the path by default.")
+	if !i.flags.Parsed() {
+		if err := i.flags.Parse(subcommandArgs); err != nil {
+			return err
+		}
+	}
+	if i.flags.NArg() != 1 {
+		return errors.New("import requires the path of a journal to import")
+	}
+	source := i.flags.Arg(0)
+	if *format == "" {
+		*format = detectImportFormat(source)
+	}
+	location := ctx.Value(CommandContextKey("date")).(time.Time).Location()
+	var imported []importedEntry
+	var err error
+	switch *format {
+	case importJrnl:
+		imported, err = readJrnlText(source, location)
+	case importDayOne:
+		imported, err = i.readDayOne(source)
+	case importMarkdown:
+		imported, err = i.readMarkdownDirectory(source, location)
+	default:
+		err = fmt.Errorf("unknown import format %q, expected jrnl, dayone or markdown", *format)
+	}
+	if err != nil {
+		return err
+	}
+	j, err := openJournal(i.options, i.store)
+	if err != nil {
+		return err
+	}
+	names, err := i.merge(j, imported)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		fmt.Fprintln(i.consoleWriter, i.store.Path(name))
+	}
+	fmt.Fprintf(i.consoleWriter, "imported %d entries into %d journal entries\n", len(imported), len(names))
+	return nil
+}
+
+func detectImportFormat(source string) string {
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		return importMarkdown
+	}
+	if strings.ToLower(filepath.Ext(source)) == ".json" {
+		return importDayOne
+	}
+	return importJrnl
+}
+
+// merge writes the imported entries. Entries of the same day, including an
+// existing entry, are combined: their text is appended in chronological
+// order, their tags are merged and the fields the entry lacks are copied.
+// Paragraphs the entry already contains are not appended again, so importing
+// twice does not duplicate them.
+func (i *ImportCommand) merge(j *journal, imported []importedEntry) ([]string, error) {
+	sort.SliceStable(imported, func(a, b int) bool {
+		return imported[a].date.Before(imported[b].date)
+	})
+	var names []string
+	entries := make(map[string]*entryHeader)
+	for _, source := range imported {
+		name := source.subject
+		if !source.date.IsZero() {
+			name = i.options.entryName(source.date)
+		}
+		entry, ok := entries[name]
+		if !ok {
+			var err error
+			entry, err = j.read(name)
+			if os.IsNotExist(err) {
+				entry, err = j.newEntry(name), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			entries[name] = entry
+			names = append(names, name)
+		}
+		if date := entry.Date(); !source.date.IsZero() && (date.IsZero() || source.date.Before(date)) {
+			entry.SetDate(source.date)
+		}
+		tags := dedupe(append(entry.Tags(), source.tags...))
+		sort.Strings(tags)
+		entry.SetTags(tags)
+		for _, field := range source.fields {
+			if _, ok := entry.Field(fmt.Sprint(field.Key)); !ok {
+				entry.SetField(fmt.Sprint(field.Key), field.Value)
+			}
+		}
+		text := strings.TrimSpace(source.text)
+		if text == "" {
+			continue
+		}
+		if source.timed {
+			if !containsParagraph(entry.Content, fmt.Sprintf("**%s** %s", source.date.Format("15:04"), text)) {
+				entry.Content = appendText(entry.Content, text, source.date, true)
+			}
+		} else if containsParagraph(entry.Content, text) {
+			continue
+		} else if entry.Content == "" {
+			entry.Content = text + "\n"
+		} else {
+			entry.Content = strings.TrimRight(entry.Content, "\n") + "\n\n" + text + "\n"
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := j.write(entries[name]); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// containsParagraph reports whether content has the text as whole
+// paragraphs, rather than as part of a longer one.
+func containsParagraph(content string, text string) bool {
+	return strings.Contains("\n\n"+strings.TrimSpace(content)+"\n\n", "\n\n"+text+"\n\n")
+}
+
+// readJrnlText reads the plain text export of jrnl.sh, where each entry
+// starts with a line holding its date, time and title, and tags are words
+// prefixed with @.
+func readJrnlText(source string, location *time.Location) ([]importedEntry, error) {
+	content, err := ioutil.ReadFile(source)
+	if err != nil {
+		return nil, err
+	}
+	var imported []importedEntry
+	for number, line := range strings.Split(string(content), "\n") {
+		if match := jrnlEntryPattern.FindStringSubmatch(line); match != nil {
+			date, err := parseJrnlTime(match[1]+match[2], location)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", source, number+1, err)
+			}
+			imported = append(imported, importedEntry{date: date, text: match[3], timed: true})
+			continue
+		}
+		if len(imported) > 0 {
+			imported[len(imported)-1].text += "\n" + line
+		}
+	}
+	for i := range imported {
+		for _, match := range jrnlTagPattern.FindAllStringSubmatch(imported[i].text, -1) {
+			imported[i].tags = append(imported[i].tags, match[1])
+		}
+	}
+	return imported, nil
+}
+
+func parseJrnlTime(value string, location *time.Location) (time.Time, error) {
+	for _, format := range jrnlTimeFormats {
+		if date, err := time.ParseInLocation(format, strings.ToUpper(value), location); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse the date of the entry %q", value)
+}
+
+// readDayOne reads the JSON file of a Day One export. Its photos, which are
+// in the photos directory next to it, are attached to the journal.
+func (i *ImportCommand) readDayOne(source string) ([]importedEntry, error) {
+	content, err := ioutil.ReadFile(source)
+	if err != nil {
+		return nil, err
+	}
+	var export dayOneExport
+	if err := json.Unmarshal(content, &export); err != nil {
+		return nil, fmt.Errorf("%s: %v", source, err)
+	}
+	var imported []importedEntry
+	for _, dayOne := range export.Entries {
+		date := dayOne.CreationDate
+		if location, err := time.LoadLocation(dayOne.TimeZone); dayOne.TimeZone != "" && err == nil {
+			date = date.In(location)
+		}
+		text := dayOne.Text
+		for _, photo := range dayOne.Photos {
+			name := photo.MD5 + "." + photo.Type
+			data, err := ioutil.ReadFile(filepath.Join(filepath.Dir(source), "photos", name))
+			if err != nil {
+				return nil, err
+			}
+			if err := i.store.Attach(name, data); err != nil {
+				return nil, err
+			}
+			text = strings.Replace(text, "dayone-moment://"+photo.Identifier, "bin/"+name, -1)
+		}
+		imported = append(imported, importedEntry{date: date, text: text, tags: dayOne.Tags, timed: true})
+	}
+	return imported, nil
+}
+
+// readMarkdownDirectory reads the markdown files of a directory. Files are
+// dated by the date in their name or frontmatter, and named after their
+// subject otherwise. Images they link to are attached to the journal.
+func (i *ImportCommand) readMarkdownDirectory(source string, location *time.Location) ([]importedEntry, error) {
+	var imported []importedEntry
+	err := filepath.Walk(source, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		extension := strings.ToLower(filepath.Ext(file))
+		if extension != ".md" && extension != ".markdown" {
+			return nil
+		}
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		subject := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		entry := importedEntry{subject: subject}
+		document := parseFrontmatter(content)
+		entry.text = document.Content
+		entry.tags = document.Tags()
+		entry.date = markdownDate(document, location)
+		for _, field := range document.fields {
+			if field.Key != dateField && field.Key != tagsField {
+				entry.fields = append(entry.fields, field)
+			}
+		}
+		if date, err := time.ParseInLocation(entryDateFormat, datePattern.FindString(subject), location); entry.date.IsZero() && err == nil {
+			entry.date = date
+		}
+		if entry.text, err = i.attachImages(entry.text, filepath.Dir(file)); err != nil {
+			return err
+		}
+		imported = append(imported, entry)
+		return nil
+	})
+	return imported, err
+}
+
+// markdownDate returns the date of the frontmatter of a markdown note, which
+// is zero when it has none or it can not be parsed.
+func markdownDate(document *entryHeader, location *time.Location) time.Time {
+	if date, err := document.parseDate(); err == nil {
+		return date
+	}
+	value, _ := document.Field(dateField)
+	for _, format := range markdownTimeFormats {
+		if date, err := time.ParseInLocation(format, fmt.Sprint(value), location); err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
+
+// attachImages attaches the local images the text links to, relative to the
+// directory, and links them from bin/ instead. An image is renamed when a
+// different image of the same name is already attached.
+func (i *ImportCommand) attachImages(text string, directory string) (string, error) {
+	var attachErr error
+	text = imagePattern.ReplaceAllStringFunc(text, func(link string) string {
+		match := imagePattern.FindStringSubmatch(link)
+		destination := match[2]
+		if strings.Contains(destination, "://") || path.IsAbs(destination) || strings.HasPrefix(destination, "bin/") {
+			return link
+		}
+		data, err := ioutil.ReadFile(filepath.Join(directory, filepath.FromSlash(destination)))
+		if err != nil {
+			return link
+		}
+		name, err := i.attach(path.Base(destination), data)
+		if err != nil {
+			attachErr = err
+			return link
+		}
+		return fmt.Sprintf("![%s](bin/%s)", match[1], name)
+	})
+	return text, attachErr
+}
+
+// attach stores an image under its name, or under its name suffixed with
+// the hash of its data when another image has the name, and returns the
+// name it is stored under.
+func (i *ImportCommand) attach(name string, data []byte) (string, error) {
+	if existing, err := i.store.Attachment(name); err == nil && string(existing) != string(data) {
+		sum := md5.Sum(data)
+		extension := path.Ext(name)
+		name = strings.TrimSuffix(name, extension) + "-" + hex.EncodeToString(sum[:4]) + extension
+	}
+	return name, i.store.Attach(name, data)
+}
@@ -0,0 +1,143 @@
+package commands_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func TestImport(t *testing.T) {
+	source, err := ioutil.TempDir("", "jrnl-import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(source)
+	writeJournalFile(t, source, "journal.txt", "[2018-08-06 09:30] Standup @team\nDiscussed the @db migration.\n\n[2018-08-05 18:00] Late vacuum finished.\n\n[2018-08-05 18:00] Late\n\n[2018-08-06 14:00] Deployed v1.2\n")
+	writeJournalFile(t, source, "default.txt", "[2020-07-12 09:39:00 PM] Dinner @family\nPasta.\n\n[2020-07-12 07:05:00 AM] Run\n")
+	writeJournalFile(t, source, "dayone/Journal.json", `{"metadata": {"version": "1.0"}, "entries": [
+		{"creationDate": "2018-08-06T13:30:00Z", "timeZone": "Europe/Paris", "text": "Whiteboard\n\n![](dayone-moment://4F2A)", "tags": ["design"],
+		 "photos": [{"identifier": "4F2A", "md5": "a1b2", "type": "png"}]}
+	]}`)
+	writeJournalFile(t, source, "dayone/photos/a1b2.png", "png")
+	writeJournalFile(t, source, "notes/2018-08-06-retro.md", "---\ntags:\n- retro\n---\n# Retro\n\nShip smaller changes.\n")
+	writeJournalFile(t, source, "notes/plans/Release plan.md", "Steps\n\n![diagram](images/flow.png)\n")
+	writeJournalFile(t, source, "notes/plans/images/flow.png", "flow")
+	writeJournalFile(t, source, "notes/other/Other plan.md", "![](images/flow.png)\n")
+	writeJournalFile(t, source, "notes/other/images/flow.png", "other flow")
+	writeJournalFile(t, source, "obsidian/standup.md", "---\ndate: 2020-01-02\ntags: [a, b]\ntitle: Standup\n---\nhello")
+	writeJournalFile(t, source, "obsidian/2020-01-03 retro.md", "---\ndate: someday\ntags: [c]\n---\nbye\n")
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 0, 0, 0, 0, time.UTC))
+
+	inputs := []struct {
+		name        string
+		args        []string
+		output      string
+		entries     map[string]string
+		attachments map[string]string
+	}{
+		{
+			"jrnl",
+			[]string{filepath.Join(source, "journal.txt")},
+			"entries/2018-08-05.md\nentries/2018-08-06.md\nimported 4 entries into 2 journal entries\n",
+			map[string]string{
+				"2018-08-05": "---\ntags:\n- db\ndate: Sun Aug 5 2018 18:00:00 +0000 UTC\n---\nVacuumed the reporting database.\n\n**18:00** Late vacuum finished.\n\n**18:00** Late\n",
+				"2018-08-06": "---\ndate: Mon Aug 6 2018 09:30:00 +0000 UTC\ntags:\n- db\n- team\n---\n**09:30** Standup @team\nDiscussed the @db migration.\n\n**14:00** Deployed v1.2\n",
+			},
+			nil,
+		},
+		{
+			"jrnl default time format",
+			[]string{filepath.Join(source, "default.txt")},
+			"entries/2020-07-12.md\nimported 2 entries into 1 journal entries\n",
+			map[string]string{
+				"2020-07-12": "---\ndate: Sun Jul 12 2020 07:05:00 +0000 UTC\ntags:\n- family\n---\n**07:05** Run\n\n**21:39** Dinner @family\nPasta.\n",
+			},
+			nil,
+		},
+		{
+			"dayone",
+			[]string{filepath.Join(source, "dayone", "Journal.json")},
+			"entries/2018-08-06.md\nimported 1 entries into 1 journal entries\n",
+			map[string]string{
+				"2018-08-06": "---\ndate: Mon Aug 6 2018 15:30:00 +0200 CEST\ntags:\n- design\n---\n**15:30** Whiteboard\n\n![](bin/a1b2.png)\n",
+			},
+			map[string]string{"a1b2.png": "png"},
+		},
+		{
+			"markdown",
+			[]string{"-format", "markdown", filepath.Join(source, "notes")},
+			"entries/2018-08-06.md\nentries/Other plan.md\nentries/Release plan.md\nimported 3 entries into 3 journal entries\n",
+			map[string]string{
+				"2018-08-06":   "---\ndate: Mon Aug 6 2018 00:00:00 +0000 UTC\ntags:\n- retro\n---\n# Retro\n\nShip smaller changes.\n",
+				"Other plan":   "![](bin/flow.png)\n",
+				"Release plan": "Steps\n\n![diagram](bin/flow-cff54971.png)\n",
+			},
+			map[string]string{"flow.png": "other flow", "flow-cff54971.png": "flow"},
+		},
+		{
+			"markdown frontmatter",
+			[]string{"-format", "markdown", filepath.Join(source, "obsidian")},
+			"entries/2020-01-02.md\nentries/2020-01-03.md\nimported 2 entries into 2 journal entries\n",
+			map[string]string{
+				"2020-01-02": "---\ndate: Thu Jan 2 2020 00:00:00 +0000 UTC\ntags:\n- a\n- b\ntitle: Standup\n---\nhello\n",
+				"2020-01-03": "---\ndate: Fri Jan 3 2020 00:00:00 +0000 UTC\ntags:\n- c\n---\nbye\n",
+			},
+			nil,
+		},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			store := fixtureStore(t)
+			if input.name == "dayone" {
+				if _, err := time.LoadLocation("Europe/Paris"); err != nil {
+					t.Skip("time zone data is not installed")
+				}
+			}
+			for run := 0; run < 2; run++ {
+				r, w, _ := os.Pipe()
+				if err := commands.NewImportCommand(commands.Configuration{}, store, w).Run(ctx, input.args); err != nil {
+					t.Fatal(err)
+				}
+				w.Close()
+				output, _ := ioutil.ReadAll(r)
+				if input.output != string(output) {
+					t.Errorf("Expected %q, got %q", input.output, string(output))
+				}
+			}
+			for name, expected := range input.entries {
+				if content := readEntry(t, store, name); expected != content {
+					t.Errorf("Expected %s to be %q, got %q", name, expected, content)
+				}
+			}
+			for name, expected := range input.attachments {
+				if content, err := store.Attachment(name); err != nil || expected != string(content) {
+					t.Errorf("Expected attachment %s to be %q, got %q %v", name, expected, content, err)
+				}
+			}
+		})
+	}
+}
+
+func TestImportInvalidDate(t *testing.T) {
+	source, err := ioutil.TempDir("", "jrnl-import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(source)
+	writeJournalFile(t, source, "journal.txt", "[2018-08-06 09:30] Standup\n\n[2018-08-06 9h30] Deployed\n")
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 0, 0, 0, 0, time.UTC))
+	store := fixtureStore(t)
+	err = commands.NewImportCommand(commands.Configuration{}, store, os.Stdout).Run(ctx, []string{filepath.Join(source, "journal.txt")})
+	if err == nil || !strings.Contains(err.Error(), "journal.txt:3:") {
+		t.Errorf("Expected an error on line 3, got %v", err)
+	}
+	if _, err := store.Read("2018-08-06"); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be imported, got %v", err)
+	}
+}
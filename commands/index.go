@@ -1,51 +1,91 @@
 package commands
 
 import (
+	"bytes"
 	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"path"
+	"path/filepath"
 	"sort"
 	"strings"
-	"sync"
+	"text/template"
+	"time"
 )
 
+// defaultIndexTemplate renders a bullet per tag with links to its entries.
+const defaultIndexTemplate = `{{range $tag, $entries := .Tags}}
+* *{{$tag}}* {{range $i, $entry := $entries}}{{if $i}}, {{end}}[{{$entry.Name}}]({{$entry.Name}}){{end}}{{end}}`
+
 type IndexCommand struct {
 	options Configuration
 	flags   *flag.FlagSet
+	store   EntryStore
+}
+
+// journalIndex is what the index pages are generated from: the entries of a
+// journal and the names of the entries using each tag.
+type journalIndex struct {
+	Tags    map[string][]string
+	Entries []indexEntry
+}
+
+// indexEntry describes an entry in the index pages.
+type indexEntry struct {
+	Name string
+	// Date is zero for entries without a date.
+	Date time.Time
+	Tags []string
+	// Summary is the first line of the content.
+	Summary string
 }
 
-func tagMap(journalPath string) (map[string][]string, error) {
-	directory := journalPath + "/entries"
-	files, err := ioutil.ReadDir(directory)
+// indexTemplateData is available to index templates.
+type indexTemplateData struct {
+	// Tags maps each tag to its entries, sorted by name. Templates range over
+	// the tags in alphabetical order.
+	Tags    map[string][]indexEntry
+	Entries []indexEntry
+}
+
+func tagMap(j *journal, period dateRange) (*journalIndex, error) {
+	entries, err := j.entries()
 	if err != nil {
 		return nil, err
 	}
-	index := make(map[string][]string)
-	results := make(chan frontmatterResult, len(files))
-	var wg sync.WaitGroup
-	for _, file := range files {
-		wg.Add(1)
-		go func(filePath string) {
-			defer wg.Done()
-			readFrontmatter(filePath, results)
-		}(fmt.Sprintf("%s/%s", directory, file.Name()))
-	}
-	wg.Wait()
-	close(results)
-	for result := range results {
-		if result.err != nil {
-			return nil, result.err
+	return newJournalIndex(entries, period), nil
+}
+
+// newJournalIndex indexes the entries within the period.
+func newJournalIndex(entries []*entryHeader, period dateRange) *journalIndex {
+	index := &journalIndex{Tags: make(map[string][]string)}
+	for _, entry := range entries {
+		if !period.includes(entry) {
+			continue
 		}
-		for _, tag := range result.header.Tags {
-			index[tag] = append(index[tag], strings.TrimSuffix(result.header.Filename, ".md"))
+		indexed := newIndexEntry(entry, period.filenameFormat)
+		index.Entries = append(index.Entries, indexed)
+		for _, tag := range indexed.Tags {
+			index.Tags[tag] = append(index.Tags[tag], indexed.Name)
 		}
 	}
-	for tag := range index {
-		sort.Strings(index[tag])
+	for tag := range index.Tags {
+		sort.Strings(index.Tags[tag])
+	}
+	return index
+}
+
+// newIndexEntry describes an entry, dating it by its filename when it has
+// no date.
+func newIndexEntry(entry *entryHeader, filenameFormat string) indexEntry {
+	date, _ := entryDate(entry, filenameFormat, time.UTC)
+	return indexEntry{
+		Name:    strings.TrimSuffix(entry.Filename, ".md"),
+		Date:    date,
+		Tags:    entry.Tags(),
+		Summary: summary(entry.Content),
 	}
-	return index, nil
 }
 
 func sortedTagKeys(index map[string][]string) []string {
@@ -57,11 +97,23 @@ func sortedTagKeys(index map[string][]string) []string {
 	return keys
 }
 
+// summary returns the first line of content, without its markdown list or
+// heading marker.
+func summary(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(strings.TrimLeft(line, "#-*> \t")); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
 // NewIndexCommand creates a new command runner for index command
-func NewIndexCommand(config Configuration) *IndexCommand {
+func NewIndexCommand(config Configuration, store EntryStore) *IndexCommand {
 	indexCommand := IndexCommand{
 		options: config,
 		flags:   flag.NewFlagSet("index", flag.ExitOnError),
+		store:   store,
 	}
 	return &indexCommand
 }
@@ -69,6 +121,9 @@ func NewIndexCommand(config Configuration) *IndexCommand {
 // Run the index command
 func (i *IndexCommand) Run(ctx context.Context, subcommandArgs []string) error {
 	outputPath := i.flags.String("o", "Index.md", "Output path contained to the $JOURNAL_PATH.")
+	templatePath := i.flags.String("template", "", "Go template to render the index with, relative to the $JOURNAL_PATH.")
+	pages := i.flags.Bool("pages", false, "Also write an Archive, a Tag-<tag> page per tag and a _Sidebar linking them.")
+	periodFlags := newDateRangeFlags(i.flags)
 	if !i.flags.Parsed() {
 		if err := i.flags.Parse(subcommandArgs); err != nil {
 			return err
@@ -77,23 +132,194 @@ func (i *IndexCommand) Run(ctx context.Context, subcommandArgs []string) error {
 	if *outputPath == "." {
 		*outputPath = "Index.md"
 	}
-	index, err := tagMap(i.options.JournalPath)
+	period, err := periodFlags.parse(ctx, i.options)
+	if err != nil {
+		return err
+	}
+	j, err := openJournal(i.options, i.store)
+	if err != nil {
+		return err
+	}
+	index, err := tagMap(j, period)
+	if err != nil {
+		return err
+	}
+	if *pages {
+		if err := tagPageCollision(sortedTagKeys(index.Tags)); err != nil {
+			return err
+		}
+	}
+	newIndex, err := i.render(index, *templatePath)
+	if err != nil {
+		return err
+	}
+	if err := i.store.WritePage(path.Base(*outputPath), newIndex); err != nil {
+		return err
+	}
+	if !*pages {
+		return nil
+	}
+	if err := i.store.WritePage("Archive.md", []byte(archivePage(index))); err != nil {
+		return err
+	}
+	written := make(map[string]bool, len(index.Tags))
+	for _, tag := range sortedTagKeys(index.Tags) {
+		if err := i.store.WritePage(tagPageName(tag)+".md", []byte(tagPage(index, tag))); err != nil {
+			return err
+		}
+		written[tagPageName(tag)+".md"] = true
+	}
+	if err := i.deleteStaleTagPages(written); err != nil {
+		return err
+	}
+	indexName := strings.TrimSuffix(path.Base(*outputPath), ".md")
+	return i.store.WritePage("_Sidebar.md", []byte(sidebarPage(index, indexName)))
+}
+
+// deleteStaleTagPages removes the pages of tags no longer used, so the
+// pages only change with the tags.
+func (i *IndexCommand) deleteStaleTagPages(written map[string]bool) error {
+	pages, err := i.store.Pages()
 	if err != nil {
 		return err
 	}
-	keys := sortedTagKeys(index)
-	var newIndex string
-	for _, tag := range keys {
-		newIndex += fmt.Sprintf("\n* *%s* ", tag)
-		mappedEntries := make([]string, len(index[tag]))
-		mapper := func(entry string) string {
-			return fmt.Sprintf("[%s](%s)", entry, entry)
+	for _, page := range pages {
+		if strings.HasPrefix(page, "Tag-") && path.Ext(page) == ".md" && !written[page] {
+			if err := i.store.DeletePage(page); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// render executes the index template, or the default template when no path
+// is given.
+func (i *IndexCommand) render(index *journalIndex, templatePath string) ([]byte, error) {
+	source := defaultIndexTemplate
+	if templatePath != "" {
+		if !filepath.IsAbs(templatePath) {
+			templatePath = filepath.Join(i.options.JournalPath, templatePath)
+		}
+		content, err := ioutil.ReadFile(templatePath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read template %q: %v", templatePath, err)
+		}
+		source = string(content)
+	}
+	indexTemplate, err := template.New("index").Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	data := indexTemplateData{
+		Tags:    make(map[string][]indexEntry, len(index.Tags)),
+		Entries: index.Entries,
+	}
+	entries := index.byName()
+	for tag, names := range index.Tags {
+		for _, name := range names {
+			data.Tags[tag] = append(data.Tags[tag], entries[name])
+		}
+	}
+	var output bytes.Buffer
+	if err := indexTemplate.Execute(&output, data); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+
+// chronological returns the entries of the index by date, followed by the
+// entries without a date.
+func (index *journalIndex) chronological() []indexEntry {
+	entries := append([]indexEntry{}, index.Entries...)
+	sort.SliceStable(entries, func(a, b int) bool {
+		if entries[a].Date.IsZero() != entries[b].Date.IsZero() {
+			return entries[b].Date.IsZero()
+		}
+		return entries[a].Date.Before(entries[b].Date)
+	})
+	return entries
+}
+
+// byName returns the entries of the index keyed by name.
+func (index *journalIndex) byName() map[string]indexEntry {
+	entries := make(map[string]indexEntry, len(index.Entries))
+	for _, entry := range index.Entries {
+		entries[entry.Name] = entry
+	}
+	return entries
+}
+
+// tagPageName is the wiki page of a tag. Wiki page names use dashes for spaces.
+func tagPageName(tag string) string {
+	return "Tag-" + strings.Join(strings.FieldsFunc(tag, func(r rune) bool {
+		return r == ' ' || r == '/' || r == '\\'
+	}), "-")
+}
+
+// tagPageCollision returns an error when two tags have the same page, ie:
+// "on call" and "on/call". Names are compared ignoring case for
+// case-insensitive file systems.
+func tagPageCollision(tags []string) error {
+	pages := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		name := tagPageName(tag)
+		if existing, ok := pages[strings.ToLower(name)]; ok {
+			return fmt.Errorf("tags %q and %q would both have the page %s, rename one of them with retag", existing, tag, name)
 		}
-		for i, entry := range index[tag] {
-			mappedEntries[i] = mapper(entry)
+		pages[strings.ToLower(name)] = tag
+	}
+	return nil
+}
+
+// archivePage lists the entries chronologically by year and month, followed
+// by the entries without a date.
+func archivePage(index *journalIndex) string {
+	page := "# Archive\n"
+	var year, month string
+	for _, entry := range index.chronological() {
+		if entry.Date.IsZero() {
+			if year != "Undated" {
+				year = "Undated"
+				page += "\n## Undated\n\n"
+			}
+		} else {
+			if entryYear := entry.Date.Format("2006"); entryYear != year {
+				year, month = entryYear, ""
+				page += fmt.Sprintf("\n## %s\n", year)
+			}
+			if entryMonth := entry.Date.Format("January"); entryMonth != month {
+				month = entryMonth
+				page += fmt.Sprintf("\n### %s\n\n", month)
+			}
 		}
-		newIndex += strings.Join(mappedEntries, ", ")
+		page += indexEntryLine(entry)
+	}
+	return page
+}
+
+// tagPage lists the entries using a tag with their summary.
+func tagPage(index *journalIndex, tag string) string {
+	entries := index.byName()
+	page := fmt.Sprintf("# %s\n\n", tag)
+	for _, name := range index.Tags[tag] {
+		page += indexEntryLine(entries[name])
+	}
+	return page
+}
+
+func indexEntryLine(entry indexEntry) string {
+	if entry.Summary == "" {
+		return fmt.Sprintf("* [%s](%s)\n", entry.Name, entry.Name)
+	}
+	return fmt.Sprintf("* [%s](%s) %s\n", entry.Name, entry.Name, entry.Summary)
+}
+
+// sidebarPage links the index, the archive and the page of each tag.
+func sidebarPage(index *journalIndex, indexName string) string {
+	page := fmt.Sprintf("* [%s](%s)\n* [Archive](Archive)\n\n**Tags**\n\n", indexName, indexName)
+	for _, tag := range sortedTagKeys(index.Tags) {
+		page += fmt.Sprintf("* [%s](%s) (%d)\n", tag, tagPageName(tag), len(index.Tags[tag]))
 	}
-	indexPath := fmt.Sprintf("%s/%s", i.options.JournalPath, path.Base(*outputPath))
-	return ioutil.WriteFile(indexPath, []byte(newIndex), 0644)
+	return page
 }
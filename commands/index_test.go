@@ -0,0 +1,122 @@
+package commands_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func TestIndex(t *testing.T) {
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 0, 0, 0, 0, time.UTC))
+	store := fixtureStore(t)
+	store.Write("Release plan", []byte("---\ntags:\n- release notes\n---\n## Steps\n"))
+	if err := commands.NewIndexCommand(commands.Configuration{}, store).Run(ctx, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Page("Archive.md"); err == nil {
+		t.Error("Expected pages to only be written with -pages")
+	}
+	if err := commands.NewIndexCommand(commands.Configuration{}, store).Run(ctx, []string{"-pages", "-since", "2018-08-03"}); err != nil {
+		t.Fatal(err)
+	}
+	inputs := []struct {
+		page     string
+		expected string
+	}{
+		{"Index.md", "\n* *db* [2018-08-03](2018-08-03), [2018-08-05](2018-08-05)\n* *incident* [2018-08-03](2018-08-03), [2018-08-04](2018-08-04)\n* *resolved* [2018-08-03](2018-08-03)"},
+		{"Archive.md", "# Archive\n\n## 2018\n\n### August\n\n* [2018-08-03](2018-08-03) Raised the pool size and the incident is resolved.\n* [2018-08-04](2018-08-04) Deploy rolled back after failed health checks.\n* [2018-08-05](2018-08-05) Vacuumed the reporting database.\n"},
+		{"Tag-db.md", "# db\n\n* [2018-08-03](2018-08-03) Raised the pool size and the incident is resolved.\n* [2018-08-05](2018-08-05) Vacuumed the reporting database.\n"},
+		{"_Sidebar.md", "* [Index](Index)\n* [Archive](Archive)\n\n**Tags**\n\n* [db](Tag-db) (2)\n* [incident](Tag-incident) (2)\n* [resolved](Tag-resolved) (1)\n"},
+	}
+	for _, input := range inputs {
+		t.Run(input.page, func(t *testing.T) {
+			content, err := store.Page(input.page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if input.expected != string(content) {
+				t.Errorf("Expected %q, got %q", input.expected, string(content))
+			}
+		})
+	}
+
+	if err := commands.NewIndexCommand(commands.Configuration{}, store).Run(ctx, []string{"-pages"}); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := store.Page("Archive.md")
+	if expected := "\n## Undated\n\n* [Release plan](Release plan) Steps\n"; !strings.HasSuffix(string(content), expected) {
+		t.Errorf("Expected undated entries last, got %q", string(content))
+	}
+	if content, _ := store.Page("Tag-release-notes.md"); string(content) != "# release notes\n\n* [Release plan](Release plan) Steps\n" {
+		t.Errorf("Expected a page for the tag with spaces, got %q", string(content))
+	}
+
+	store.Write("Release plan", []byte("## Steps\n"))
+	if err := commands.NewIndexCommand(commands.Configuration{}, store).Run(ctx, []string{"-pages"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Page("Tag-release-notes.md"); err == nil {
+		t.Error("Expected the page of an unused tag to be removed")
+	}
+	if _, err := store.Page("Tag-db.md"); err != nil {
+		t.Errorf("Expected the page of a used tag to be kept, got %v", err)
+	}
+
+	store.Write("2018-08-06", []byte("---\ntags:\n- on call\n- on/call\n---\nPaged twice.\n"))
+	err := commands.NewIndexCommand(commands.Configuration{}, store).Run(ctx, []string{"-pages"})
+	if err == nil || !strings.Contains(err.Error(), "Tag-on-call") {
+		t.Errorf("Expected tags sharing a page to fail, got %v", err)
+	}
+}
+
+func TestIndexTemplate(t *testing.T) {
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 0, 0, 0, 0, time.UTC))
+	journalPath, err := ioutil.TempDir("", "jrnl-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(journalPath)
+	source := "| Tag | Entries |\n|---|---|\n{{range $tag, $entries := .Tags}}| {{$tag}} | {{len $entries}} |\n{{end}}{{range .Entries}}{{if .Tags}}\n## {{.Date.Format \"Jan 2\"}}: {{.Summary}}\n{{end}}{{end}}"
+	if err := ioutil.WriteFile(filepath.Join(journalPath, "table.tmpl"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	inputs := []struct {
+		name         string
+		args         []string
+		expected     string
+		expectsError bool
+	}{
+		{
+			"table",
+			[]string{"-template", "table.tmpl", "-since", "2018-08-04"},
+			"| Tag | Entries |\n|---|---|\n| db | 1 |\n| incident | 1 |\n\n## Aug 4: Deploy rolled back after failed health checks.\n\n## Aug 5: Vacuumed the reporting database.\n",
+			false,
+		},
+		{"missing template", []string{"-template", "missing.tmpl"}, "", true},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			store := fixtureStore(t)
+			err := commands.NewIndexCommand(commands.Configuration{JournalPath: journalPath}, store).Run(ctx, input.args)
+			if input.expectsError != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", input.expectsError, err)
+			}
+			if input.expectsError {
+				return
+			}
+			content, err := store.Page("Index.md")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if input.expected != string(content) {
+				t.Errorf("Expected %q, got %q", input.expected, string(content))
+			}
+		})
+	}
+}
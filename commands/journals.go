@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+type JournalsCommand struct {
+	options       Configuration
+	flags         *flag.FlagSet
+	consoleWriter *os.File
+}
+
+// NewJournalsCommand creates a new command runner for listing named journals
+func NewJournalsCommand(config Configuration, consoleWriter *os.File) *JournalsCommand {
+	journalsCommand := JournalsCommand{
+		options:       config,
+		flags:         flag.NewFlagSet("journals", flag.ExitOnError),
+		consoleWriter: consoleWriter,
+	}
+	return &journalsCommand
+}
+
+// Run the journals command
+func (j *JournalsCommand) Run(ctx context.Context, subcommandArgs []string) error {
+	if !j.flags.Parsed() {
+		if err := j.flags.Parse(subcommandArgs); err != nil {
+			return err
+		}
+	}
+	writer := tabwriter.NewWriter(j.consoleWriter, 0, 0, 2, ' ', 0)
+	if len(j.options.Journals) == 0 {
+		fmt.Fprintf(writer, "*\t%s\t%s\t%s\n", "default", j.options.JournalPath, entryCount(j.options.JournalPath))
+		return writer.Flush()
+	}
+	for _, name := range sortedJournalNames(j.options.Journals) {
+		journalPath := expandHome(j.options.Journals[name])
+		marker := ""
+		if name == j.options.Journal {
+			marker = "*"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", marker, name, journalPath, entryCount(journalPath))
+	}
+	return writer.Flush()
+}
+
+func sortedJournalNames(journals map[string]string) []string {
+	names := make([]string, 0, len(journals))
+	for name := range journals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func entryCount(journalPath string) string {
+	names, err := NewFileStore(journalPath).List()
+	if err != nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d entries", len(names))
+}
@@ -1,30 +1,164 @@
 package commands
 
-import "context"
-import "fmt"
-import "os"
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
 
 type ListTagsCommand struct {
-	options Configuration
+	options       Configuration
+	flags         *flag.FlagSet
+	store         EntryStore
+	consoleWriter *os.File
+}
+
+type tagUsage struct {
+	tag      string
+	entries  []string
+	lastUsed time.Time
 }
 
 // NewListTagsCommand creates a new command runner for listing tags.
-func NewListTagsCommand(config Configuration) *ListTagsCommand {
+func NewListTagsCommand(config Configuration, store EntryStore, consoleWriter *os.File) *ListTagsCommand {
 	listTagsCommand := ListTagsCommand{
-		options: config,
+		options:       config,
+		flags:         flag.NewFlagSet("list-tags", flag.ExitOnError),
+		store:         store,
+		consoleWriter: consoleWriter,
 	}
 	return &listTagsCommand
 }
 
 // Run the list-tags command
-func (l *ListTagsCommand) Run(ctd context.Context, subcommandArgs []string) error {
-	index, err := tagMap(l.options.JournalPath)
+func (l *ListTagsCommand) Run(ctx context.Context, subcommandArgs []string) error {
+	periodFlags := newDateRangeFlags(l.flags)
+	format := newFormatFlag(l.flags)
+	showCount := l.flags.Bool("count", false, "Show the number of entries using each tag and when it was last used.")
+	sortBy := l.flags.String("sort", "name", "Sort tags by name, count or recent.")
+	minimum := l.flags.Int("min", 0, "Only list tags used by at least this many entries.")
+	if !l.flags.Parsed() {
+		if err := l.flags.Parse(subcommandArgs); err != nil {
+			return err
+		}
+	}
+	if err := validateFormat(*format); err != nil {
+		return err
+	}
+	period, err := periodFlags.parse(ctx, l.options)
+	if err != nil {
+		return err
+	}
+	j, err := openJournal(l.options, l.store)
 	if err != nil {
 		return err
 	}
-	tags := sortedTagKeys(index)
-	for _, tag := range tags {
-		fmt.Fprintf(os.Stdout, "%s\n", tag)
+	entries, err := j.entries()
+	if err != nil {
+		return err
+	}
+	location := ctx.Value(CommandContextKey("date")).(time.Time).Location()
+	usages := tagUsages(entries, period, l.options.filenameDateFormat(), location)
+	filtered := usages[:0]
+	for _, usage := range usages {
+		if len(usage.entries) >= *minimum {
+			filtered = append(filtered, usage)
+		}
+	}
+	if err := sortTagUsages(filtered, *sortBy); err != nil {
+		return err
+	}
+	if *format != formatText {
+		records := make([]interface{}, len(filtered))
+		for i, usage := range filtered {
+			records[i] = usage.record()
+		}
+		return writeRecords(l.consoleWriter, *format, records)
+	}
+	if !*showCount {
+		for _, usage := range filtered {
+			fmt.Fprintf(l.consoleWriter, "%s\n", usage.tag)
+		}
+		return nil
 	}
+	writer := tabwriter.NewWriter(l.consoleWriter, 0, 0, 2, ' ', 0)
+	for _, usage := range filtered {
+		fmt.Fprintf(writer, "%s\t%d\t%s\n", usage.tag, len(usage.entries), usage.lastUsedDate())
+	}
+	return writer.Flush()
+}
+
+func tagUsages(entries []*entryHeader, period dateRange, filenameFormat string, location *time.Location) []tagUsage {
+	byTag := make(map[string]*tagUsage)
+	for _, entry := range entries {
+		if !period.includes(entry) {
+			continue
+		}
+		date, _ := entryDate(entry, filenameFormat, location)
+		for _, tag := range entry.Tags() {
+			usage, ok := byTag[tag]
+			if !ok {
+				usage = &tagUsage{tag: tag}
+				byTag[tag] = usage
+			}
+			usage.entries = append(usage.entries, strings.TrimSuffix(entry.Filename, ".md"))
+			if date.After(usage.lastUsed) {
+				usage.lastUsed = date
+			}
+		}
+	}
+	usages := make([]tagUsage, 0, len(byTag))
+	for _, usage := range byTag {
+		sort.Strings(usage.entries)
+		usages = append(usages, *usage)
+	}
+	return usages
+}
+
+func sortTagUsages(usages []tagUsage, sortBy string) error {
+	var less func(a, b tagUsage) bool
+	switch sortBy {
+	case "name":
+		less = func(a, b tagUsage) bool { return false }
+	case "count":
+		less = func(a, b tagUsage) bool { return len(a.entries) > len(b.entries) }
+	case "recent":
+		less = func(a, b tagUsage) bool { return a.lastUsed.After(b.lastUsed) }
+	default:
+		return fmt.Errorf("unknown sort order %q", sortBy)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if less(usages[i], usages[j]) {
+			return true
+		}
+		if less(usages[j], usages[i]) {
+			return false
+		}
+		return usages[i].tag < usages[j].tag
+	})
 	return nil
 }
+
+func (t tagUsage) lastUsedDate() string {
+	if t.lastUsed.IsZero() {
+		return "-"
+	}
+	return t.lastUsed.Format(entryDateFormat)
+}
+
+func (t tagUsage) record() tagRecord {
+	record := tagRecord{
+		Tag:     t.tag,
+		Count:   len(t.entries),
+		Entries: t.entries,
+	}
+	if !t.lastUsed.IsZero() {
+		record.LastUsed = t.lastUsed.Format(entryDateFormat)
+	}
+	return record
+}
@@ -0,0 +1,87 @@
+package commands_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func TestListTags(t *testing.T) {
+	path, _ := filepath.Abs("../fixtures")
+	config := commands.Configuration{
+		JournalPath: path,
+	}
+	inputs := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"text", []string{}, "bar\ndb\nfoo\nincident\nresolved\n"},
+		{"date range", []string{"-since", "2018-08-04"}, "db\nincident\n"},
+		{"jsonl", []string{"-format", "jsonl", "-until", "2018-08-02"}, `{"tag":"bar","count":1,"last_used":"2018-08-01","entries":["2018-08-01"]}
+{"tag":"db","count":1,"last_used":"2018-08-02","entries":["2018-08-02"]}
+{"tag":"foo","count":1,"last_used":"2018-08-01","entries":["2018-08-01"]}
+{"tag":"incident","count":1,"last_used":"2018-08-02","entries":["2018-08-02"]}
+`},
+		{"json", []string{"-format", "json", "-since", "2018-08-05"}, `[
+  {
+    "tag": "db",
+    "count": 1,
+    "last_used": "2018-08-05",
+    "entries": [
+      "2018-08-05"
+    ]
+  }
+]
+`},
+		{"count", []string{"-count"}, "bar       1  2018-08-01\ndb        3  2018-08-05\nfoo       1  2018-08-01\nincident  3  2018-08-04\nresolved  1  2018-08-03\n"},
+		{"sort count", []string{"-sort", "count", "-min", "2"}, "db\nincident\n"},
+		{"sort recent", []string{"-sort", "recent"}, "db\nincident\nresolved\nbar\nfoo\n"},
+		{"minimum", []string{"-min", "3", "-count"}, "db        3  2018-08-05\nincident  3  2018-08-04\n"},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			r, w, _ := os.Pipe()
+			cmd := commands.NewListTagsCommand(config, commands.NewFileStore(path), w)
+			ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 6, 0, 0, 0, 0, time.UTC))
+			if err := cmd.Run(ctx, input.args); err != nil {
+				t.Fatal(err)
+			}
+			w.Close()
+			output, _ := ioutil.ReadAll(r)
+			if input.expected != string(output) {
+				t.Errorf("Expected %v, got %v", input.expected, string(output))
+			}
+		})
+	}
+}
+
+func TestListTagsMalformedEntries(t *testing.T) {
+	store := fixtureStore(t)
+	entries := map[string]string{
+		"2018-08-06": "---\ntags: [a]\n---",
+		"2018-08-07": "---\ntags: [b\n---\nUnclosed tags.\n",
+		"2018-08-08": "---\r\ntags:\r\n- c\r\n---\r\nWindows.\r\n",
+	}
+	for name, content := range entries {
+		if err := store.Write(name, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, w, _ := os.Pipe()
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 8, 0, 0, 0, 0, time.UTC))
+	if err := commands.NewListTagsCommand(commands.Configuration{}, store, w).Run(ctx, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	output, _ := ioutil.ReadAll(r)
+	expected := "a\nbar\nc\ndb\nfoo\nincident\nresolved\n"
+	if expected != string(output) {
+		t.Errorf("Expected %v, got %v", expected, string(output))
+	}
+}
@@ -2,71 +2,197 @@ package commands
 
 import (
 	"context"
-	"errors"
+	"flag"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
 )
 
 type MemorizeCommand struct {
 	options Configuration
+	flags   *flag.FlagSet
+	store   EntryStore
+	runner  GitCommandRunner
+}
+
+// journalChanges are the changed files of a journal, grouped by kind.
+type journalChanges struct {
+	added    []string
+	modified []string
+	deleted  []string
+	images   []string
+	other    []string
+	tags     []string
 }
 
 // NewMemorizeCommand creates a new command runner for memorize command
-func NewMemorizeCommand(config Configuration) *MemorizeCommand {
+func NewMemorizeCommand(config Configuration, store EntryStore, runner GitCommandRunner) *MemorizeCommand {
 	memorizeCommand := MemorizeCommand{
 		options: config,
+		flags:   flag.NewFlagSet("memorize", flag.ExitOnError),
+		store:   store,
+		runner:  runner,
 	}
 	return &memorizeCommand
 }
 
 // Run the memorize command
 func (m *MemorizeCommand) Run(ctx context.Context, subcommandArgs []string) error {
-	params := []string{
-		"-C",
-		m.options.JournalPath,
-		"add",
-		".",
+	message := m.flags.String("m", "", "Commit message, instead of one describing the changes.")
+	noPush := m.flags.Bool("no-push", false, "Commit without pushing, ie: when offline.")
+	if !m.flags.Parsed() {
+		if err := m.flags.Parse(subcommandArgs); err != nil {
+			return err
+		}
+	}
+	if err := m.runner.Add(m.options.JournalPath, "."); err != nil {
+		return err
+	}
+	status, err := m.runner.Status(m.options.JournalPath)
+	if err != nil {
+		return err
+	}
+	changes := m.stagedChanges(status)
+	if !changes.empty() {
+		if *message == "" {
+			*message = changes.message()
+		}
+		if err := m.runner.Commit(m.options.JournalPath, *message); err != nil && !gitErrorIs(err, ErrNothingToCommit) {
+			return err
+		}
 	}
+	if *noPush {
+		return nil
+	}
+	// Entries memorized earlier may still need to be pushed.
+	remote, branch, err := m.options.pushTarget(status)
+	if err != nil {
+		return err
+	}
+	return m.runner.Push(m.options.JournalPath, remote, branch)
+}
+
+func (m *MemorizeCommand) stagedChanges(status GitStatus) journalChanges {
+	changes := newJournalChanges(status.Files, true)
+	changes.tags = m.changedTags(changes)
+	return changes
+}
 
-	if code := gitCommand(params...); code != 0 {
-		switch code {
-		case 128:
-			break
+// newJournalChanges groups changed files. Only the changes in the index are
+// considered when staged is set, otherwise the working tree is compared to
+// the last commit.
+func newJournalChanges(files []GitFileStatus, staged bool) journalChanges {
+	var changes journalChanges
+	for _, file := range files {
+		if len(file.Code) != 2 {
+			continue
+		}
+		index, tree := file.Code[0], file.Code[1]
+		if staged && (index == '.' || index == '?') {
+			continue
+		}
+		switch {
+		case path.Dir(file.Path) == "bin":
+			changes.images = append(changes.images, file.Path)
+		case path.Dir(file.Path) != "entries" || path.Ext(file.Path) != ".md":
+			changes.other = append(changes.other, file.Path)
+		case index == 'D' || !staged && tree == 'D':
+			changes.deleted = append(changes.deleted, strings.TrimSuffix(path.Base(file.Path), ".md"))
+		case index == 'A' || !staged && index == '?':
+			changes.added = append(changes.added, strings.TrimSuffix(path.Base(file.Path), ".md"))
 		default:
-			return errors.New("failed to stage journal entries")
+			changes.modified = append(changes.modified, strings.TrimSuffix(path.Base(file.Path), ".md"))
 		}
 	}
+	return changes
+}
 
-	params = []string{
-		"-C",
-		m.options.JournalPath,
-		"commit",
-		"-am",
-		"Memorized journal",
+// changedTags returns the tags added to or removed from the changed entries
+// since the last commit. The commit message is only informative, so entries
+// that can not be read are skipped.
+func (m *MemorizeCommand) changedTags(changes journalChanges) []string {
+	j, err := openJournal(m.options, m.store)
+	if err != nil {
+		return nil
 	}
-
-	if code := gitCommand(params...); code != 0 {
-		switch code {
-		case 128:
-			break
-		default:
-			return errors.New("failed to commit journal")
+	var tags []string
+	for _, name := range append(append([]string{}, changes.added...), changes.modified...) {
+		if entry, err := j.read(name); err == nil {
+			tags = append(tags, tagDifference(m.committedTags(j, name), entry.Tags())...)
 		}
 	}
+	for _, name := range changes.deleted {
+		tags = append(tags, m.committedTags(j, name)...)
+	}
+	tags = dedupe(tags)
+	sort.Strings(tags)
+	return tags
+}
 
-	params = []string{
-		"-C",
-		m.options.JournalPath,
-		"push",
-		"origin",
-		"master",
+// committedTags returns the tags of an entry in the last commit, if any.
+func (m *MemorizeCommand) committedTags(j *journal, name string) []string {
+	document, err := m.runner.Show(m.options.JournalPath, "HEAD", entryRepositoryPath(name))
+	if err != nil {
+		return nil
 	}
+	entry, err := j.parse(name, document)
+	if err != nil {
+		return nil
+	}
+	return entry.Tags()
+}
 
-	if code := gitCommand(params...); code != 0 {
-		switch code {
-		case 128:
-			break
-		default:
-			return errors.New("failed to sync journal")
+// tagDifference returns the tags in only one of before and after.
+func tagDifference(before []string, after []string) []string {
+	beforeSet, afterSet := tagSet(before), tagSet(after)
+	var difference []string
+	for _, tag := range append(append([]string{}, before...), after...) {
+		if !beforeSet[tag] || !afterSet[tag] {
+			difference = append(difference, tag)
 		}
 	}
-	return nil
+	return difference
+}
+
+func (c journalChanges) empty() bool {
+	return len(c.added)+len(c.modified)+len(c.deleted)+len(c.images)+len(c.other) == 0
+}
+
+// message describes the changes, ie:
+//
+//	Memorized journal: 1 added, 1 modified
+//
+//	Added: 2018-08-07
+//	Modified: 2018-08-06
+//	Tags: db, incident
+func (c journalChanges) message() string {
+	var counts []string
+	var details []string
+	for _, group := range []struct {
+		label string
+		items []string
+		count bool
+	}{
+		{"added", c.added, true},
+		{"modified", c.modified, true},
+		{"deleted", c.deleted, true},
+		{"images", c.images, false},
+		{"other", c.other, false},
+		{"tags", c.tags, false},
+	} {
+		if len(group.items) == 0 {
+			continue
+		}
+		if group.count {
+			counts = append(counts, fmt.Sprintf("%d %s", len(group.items), group.label))
+		}
+		label := strings.ToUpper(group.label[:1]) + group.label[1:]
+		details = append(details, fmt.Sprintf("%s: %s", label, strings.Join(group.items, ", ")))
+	}
+	subject := "Memorized journal"
+	if len(counts) > 0 {
+		subject += ": " + strings.Join(counts, ", ")
+	}
+	return subject + "\n\n" + strings.Join(details, "\n")
 }
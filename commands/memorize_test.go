@@ -0,0 +1,128 @@
+package commands_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func TestMemorize(t *testing.T) {
+	changes := []commands.GitFileStatus{
+		{Path: "entries/2018-08-07.md", Code: "A."},
+		{Path: "entries/2018-08-02.md", Code: "M."},
+		{Path: "entries/2018-08-03.md", Code: ".M"},
+		{Path: "entries/2018-07-01.md", Code: "D."},
+		{Path: "bin/pixel.png", Code: "A."},
+		{Path: "Index.md", Code: "M."},
+	}
+	upstream := commands.GitStatus{Branch: "main", Upstream: "upstream/main", Files: changes}
+	inputs := []struct {
+		name         string
+		config       commands.Configuration
+		args         []string
+		status       commands.GitStatus
+		errors       map[string]error
+		expected     string
+		expectsError bool
+	}{
+		{
+			"describes changes",
+			commands.Configuration{},
+			[]string{},
+			upstream,
+			map[string]error{},
+			"add /some/path .\nstatus /some/path\ncommit /some/path Memorized journal: 1 added, 1 modified, 1 deleted\n\nAdded: 2018-08-07\nModified: 2018-08-02\nDeleted: 2018-07-01\nImages: bin/pixel.png\nOther: Index.md\nTags: db, deploy, vacation\npush /some/path upstream main",
+			false,
+		},
+		{
+			"message override",
+			commands.Configuration{},
+			[]string{"-m", "Sprint notes"},
+			upstream,
+			map[string]error{},
+			"add /some/path .\nstatus /some/path\ncommit /some/path Sprint notes\npush /some/path upstream main",
+			false,
+		},
+		{
+			"no push",
+			commands.Configuration{},
+			[]string{"-no-push", "-m", "Offline"},
+			upstream,
+			map[string]error{},
+			"add /some/path .\nstatus /some/path\ncommit /some/path Offline",
+			false,
+		},
+		{
+			"configured remote and branch",
+			commands.Configuration{GitRemote: "wiki", GitBranch: "master"},
+			[]string{"-m", "Notes"},
+			upstream,
+			map[string]error{},
+			"add /some/path .\nstatus /some/path\ncommit /some/path Notes\npush /some/path wiki master",
+			false,
+		},
+		{
+			"nothing to commit without upstream",
+			commands.Configuration{},
+			[]string{},
+			commands.GitStatus{Branch: "main"},
+			map[string]error{},
+			"add /some/path .\nstatus /some/path\npush /some/path origin main",
+			false,
+		},
+		{
+			"detached head",
+			commands.Configuration{},
+			[]string{"-m", "Notes"},
+			commands.GitStatus{Files: changes},
+			map[string]error{},
+			"add /some/path .\nstatus /some/path\ncommit /some/path Notes",
+			true,
+		},
+		{
+			"commit failure",
+			commands.Configuration{},
+			[]string{"-m", "Notes"},
+			upstream,
+			map[string]error{"commit": &commands.GitError{Reason: commands.ErrNotRepository}},
+			"add /some/path .\nstatus /some/path\ncommit /some/path Notes",
+			true,
+		},
+		{
+			"push failure",
+			commands.Configuration{},
+			[]string{"-m", "Notes"},
+			upstream,
+			map[string]error{"push": &commands.GitError{Reason: commands.ErrNonFastForward}},
+			"add /some/path .\nstatus /some/path\ncommit /some/path Notes\npush /some/path upstream main",
+			true,
+		},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			store := fixtureStore(t)
+			store.Write("2018-08-07", []byte("---\ntags:\n- deploy\n---\nDeployed"))
+			runner := newFakeGitCommand()
+			runner.files["HEAD:entries/2018-08-02.md"] = "---\ntags:\n- incident\n---\nConnection pool exhaustion."
+			runner.files["HEAD:entries/2018-07-01.md"] = "---\ntags:\n- vacation\n---\nBeach."
+			runner.status = input.status
+			for operation, err := range input.errors {
+				runner.errors[operation] = err
+			}
+			input.config.JournalPath = "/some/path"
+			cmd := commands.NewMemorizeCommand(input.config, store, runner)
+			ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.July, 28, 0, 0, 0, 0, time.UTC))
+			err := cmd.Run(ctx, input.args)
+			if err != nil && !input.expectsError {
+				t.Fatal(err)
+			} else if err == nil && input.expectsError {
+				t.Error("Expected input to produce an error")
+			}
+			if calls := runner.calls(); calls != input.expected {
+				t.Errorf("Expected %v, got %v", input.expected, calls)
+			}
+		})
+	}
+}
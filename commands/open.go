@@ -1,9 +1,9 @@
 package commands
 
 import (
+	"bytes"
 	"context"
 	"flag"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -14,6 +14,7 @@ import (
 type OpenCommand struct {
 	options       Configuration
 	flags         *flag.FlagSet
+	store         EntryStore
 	editorSpawner ExternalEditor
 }
 
@@ -31,52 +32,95 @@ func (e *ExternalEditorImpl) OpenEditor(editor string, args ...string) error {
 }
 
 // NewOpenCommand creates a new command runner for open command
-func NewOpenCommand(config Configuration, editorSpawner ExternalEditor) *OpenCommand {
+func NewOpenCommand(config Configuration, store EntryStore, editorSpawner ExternalEditor) *OpenCommand {
 	openCommand := OpenCommand{
 		options:       config,
 		flags:         flag.NewFlagSet("open", flag.ExitOnError),
+		store:         store,
 		editorSpawner: editorSpawner,
 	}
 	return &openCommand
 }
 
-func generateFrontmatter(ctx context.Context) ([]byte, error) {
-	entry := entryHeader{
-		Date: ctx.Value(CommandContextKey("date")).(time.Time),
-	}
-	return entry.MarshalFrontmatter()
-}
-
 // Run the open command
 func (o *OpenCommand) Run(ctx context.Context, subcommandArgs []string) error {
 	subjectFlag := o.flags.String("s", "", "Set the subject (this will not use a journal date.")
+	templateFlag := o.flags.String("template", "", "Template from $JOURNAL_PATH/templates to start a new entry with.")
 	if !o.flags.Parsed() {
 		if err := o.flags.Parse(subcommandArgs); err != nil {
 			return err
 		}
 	}
-	var filename string
+	var name string
 	if *subjectFlag != "" {
-		filename = *subjectFlag
+		name = *subjectFlag
 	} else {
-		filename = ctx.Value(CommandContextKey("date")).(time.Time).Format("2006-01-02")
+		name = o.options.entryName(ctx.Value(CommandContextKey("date")).(time.Time))
 	}
 	var options []string
 	if editorOptions := o.options.JournalEditorOptions; editorOptions != "" {
 		options = strings.Split(editorOptions, " ")
 	}
-	filePath := fmt.Sprintf("%s/entries/%s.md", o.options.JournalPath, filename)
-	options = append(options, filePath)
-	os.MkdirAll(o.options.JournalPath+"/entries", os.ModePerm)
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		content, err := generateFrontmatter(ctx)
+	j, err := openJournal(o.options, o.store)
+	if err != nil {
+		return err
+	}
+	entry, err := j.read(name)
+	if os.IsNotExist(err) {
+		if entry, err = generateEntry(ctx, o.options, j, *subjectFlag, *templateFlag); err == nil {
+			err = j.write(entry)
+		}
 		if err != nil {
 			return err
 		}
-		if err := ioutil.WriteFile(filePath, content, 0644); err != nil {
+	}
+	if err == nil {
+		// Fail before the editor opens rather than losing the edits.
+		if err := j.writable(entry); err != nil {
 			return err
 		}
 	}
+	// Plain entries of a local journal are edited in place. A malformed entry
+	// is still opened so it can be fixed.
+	if local, ok := o.store.(localStore); ok && j.cipher.mode == encryptionNone && (err != nil || !entry.encrypted) {
+		options = append(options, local.localPath(name))
+		return o.editorSpawner.OpenEditor(o.options.JournalEditor, options...)
+	}
+	if err != nil {
+		return err
+	}
+	return o.editCopy(j, entry, options)
+}
 
-	return o.editorSpawner.OpenEditor(o.options.JournalEditor, options...)
+// editCopy opens a copy of the entry in the editor, and writes the edited
+// copy back into the journal, encrypting it when required.
+func (o *OpenCommand) editCopy(j *journal, entry *entryHeader, options []string) error {
+	document, err := entry.MarshalFrontmatter()
+	if err != nil {
+		return err
+	}
+	tempFile, err := ioutil.TempFile("", "jrnl-*.md")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	_, err = tempFile.Write(document)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	options = append(options, tempFile.Name())
+	if err := o.editorSpawner.OpenEditor(o.options.JournalEditor, options...); err != nil {
+		return err
+	}
+	edited, err := ioutil.ReadFile(tempFile.Name())
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(edited, document) {
+		return nil
+	}
+	return j.update(entry, edited)
 }
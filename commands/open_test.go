@@ -4,7 +4,6 @@ import (
 	"context"
 	"io/ioutil"
 	"os"
-	"path/filepath"
 	"testing"
 	"time"
 
@@ -13,15 +12,24 @@ import (
 
 type fakeEditor struct {
 	called map[string][]string
+	// edited replaces the content of the opened file when set.
+	edited string
 }
 
 func (f *fakeEditor) OpenEditor(editor string, args ...string) error {
 	f.called["open_editor"] = append([]string{editor}, args...)
+	if f.edited != "" {
+		return ioutil.WriteFile(args[len(args)-1], []byte(f.edited), 0644)
+	}
 	return nil
 }
 
 func TestFileCreatedOnStartup(t *testing.T) {
-	path, _ := filepath.Abs("../fixtures")
+	path, err := ioutil.TempDir("", "jrnl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
 	expectedFilePath := path + "/entries/2018-07-28.md"
 	t.Run("FileCreatedOnStartup", func(t *testing.T) {
 		config := commands.Configuration{
@@ -31,7 +39,7 @@ func TestFileCreatedOnStartup(t *testing.T) {
 		editor := fakeEditor{
 			called: make(map[string][]string),
 		}
-		cmd := commands.NewOpenCommand(config, &editor)
+		cmd := commands.NewOpenCommand(config, commands.NewFileStore(path), &editor)
 		ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.July, 28, 0, 0, 0, 0, time.UTC))
 		if err := cmd.Run(ctx, []string{}); err != nil {
 			t.Fatal(err)
@@ -52,5 +60,108 @@ func TestFileCreatedOnStartup(t *testing.T) {
 			t.Errorf("Expected file path to be %v, got %v", expectedFilePath, editor.called["open_editor"][1])
 		}
 	})
-	os.Remove(expectedFilePath)
+}
+
+func TestOpenEditsCopy(t *testing.T) {
+	store := fixtureStore(t)
+	editor := fakeEditor{
+		called: make(map[string][]string),
+		edited: "---\ntags:\n- db\n---\nVacuumed the reporting database twice.\n",
+	}
+	cmd := commands.NewOpenCommand(commands.Configuration{JournalEditor: "vim"}, store, &editor)
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 5, 0, 0, 0, 0, time.UTC))
+	if err := cmd.Run(ctx, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(editor.called["open_editor"]) != 2 || editor.called["open_editor"][0] != "vim" {
+		t.Errorf("Expected OpenEditor to be called with a copy of the entry, got %v", editor.called["open_editor"])
+	}
+	if content := readEntry(t, store, "2018-08-05"); content != editor.edited {
+		t.Errorf("Expected %v, got %v", editor.edited, content)
+	}
+}
+
+func TestOpenWithTemplate(t *testing.T) {
+	path, err := ioutil.TempDir("", "jrnl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+	store := fixtureStore(t)
+	os.Mkdir(path+"/templates", 0755)
+	standup := "# Standup {{.Weekday}} week {{.Week}}\n\n## Carried over from {{.PreviousEntry}}\n{{range .PreviousTodos}}- [ ] {{.}}\n{{end}}"
+	if err := ioutil.WriteFile(path+"/templates/standup.md", []byte(standup), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path+"/templates/retro.md", []byte("# Retro for {{.Subject}}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	previous := "---\ndate: Mon Aug 6 2018 00:00:00 +0000 UTC\n---\n- [x] Ship it\n- [ ] Write docs\n  * [ ] Review PR\n"
+	if err := store.Write("2018-08-06", []byte(previous)); err != nil {
+		t.Fatal(err)
+	}
+	expectedStandup := "---\ndate: Tue Aug 7 2018 09:00:00 +0000 UTC\ntags:\n- daily\n---\n# Standup Tuesday week 32\n\n## Carried over from 2018-08-06\n- [ ] Write docs\n- [ ] Review PR\n"
+	inputs := []struct {
+		name         string
+		config       commands.Configuration
+		args         []string
+		entry        string
+		expected     string
+		expectsError bool
+	}{
+		{
+			"template flag",
+			commands.Configuration{JournalPath: path, DefaultTags: []string{"daily"}},
+			[]string{"-template", "standup"},
+			"2018-08-07",
+			expectedStandup,
+			false,
+		},
+		{
+			"weekday rule",
+			commands.Configuration{JournalPath: path, DefaultTags: []string{"daily"}, Templates: commands.TemplateRules{Weekdays: map[string]string{"tuesday": "standup"}}},
+			[]string{},
+			"2018-08-07",
+			expectedStandup,
+			false,
+		},
+		{
+			"subject rule",
+			commands.Configuration{JournalPath: path, DefaultTemplate: "standup", Templates: commands.TemplateRules{Subjects: map[string]string{"sprint-12": "retro"}}},
+			[]string{"-s", "sprint-12"},
+			"sprint-12",
+			"---\ndate: Tue Aug 7 2018 09:00:00 +0000 UTC\n---\n# Retro for sprint-12\n",
+			false,
+		},
+		{
+			"missing template",
+			commands.Configuration{JournalPath: path},
+			[]string{"-template", "missing"},
+			"2018-08-07",
+			"",
+			true,
+		},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			defer store.Delete(input.entry)
+			editor := fakeEditor{
+				called: make(map[string][]string),
+			}
+			cmd := commands.NewOpenCommand(input.config, store, &editor)
+			ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 9, 0, 0, 0, time.UTC))
+			err := cmd.Run(ctx, input.args)
+			if err != nil && !input.expectsError {
+				t.Fatal(err)
+			} else if err == nil && input.expectsError {
+				t.Fatal("Expected input to produce an error")
+			} else if err != nil && input.expectsError {
+				return
+			}
+			content := readEntry(t, store, input.entry)
+			if input.expected != content {
+				t.Errorf("Expected %v, got %v", input.expected, content)
+			}
+		})
+	}
 }
@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tagExpression is a parsed boolean tag query such as "incident and not resolved".
+type tagExpression interface {
+	match(tags map[string]bool) bool
+}
+
+type tagTerm string
+
+func (t tagTerm) match(tags map[string]bool) bool {
+	return tags[string(t)]
+}
+
+type notExpression struct {
+	operand tagExpression
+}
+
+func (n notExpression) match(tags map[string]bool) bool {
+	return !n.operand.match(tags)
+}
+
+type andExpression struct {
+	left, right tagExpression
+}
+
+func (a andExpression) match(tags map[string]bool) bool {
+	return a.left.match(tags) && a.right.match(tags)
+}
+
+type orExpression struct {
+	left, right tagExpression
+}
+
+func (o orExpression) match(tags map[string]bool) bool {
+	return o.left.match(tags) || o.right.match(tags)
+}
+
+type queryToken struct {
+	value  string
+	quoted bool
+}
+
+func (q queryToken) is(keyword string) bool {
+	return !q.quoted && strings.ToLower(q.value) == keyword
+}
+
+type queryParser struct {
+	tokens   []queryToken
+	position int
+}
+
+// parseTagExpression parses a query made of tags combined with "and", "or",
+// "not" and parentheses. Tags containing spaces or keywords may be quoted.
+func parseTagExpression(query string) (tagExpression, error) {
+	tokens, err := tokenizeQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty query")
+	}
+	parser := queryParser{tokens: tokens}
+	expression, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token, ok := parser.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q in query", token.value)
+	}
+	return expression, nil
+}
+
+func tokenizeQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case unicode.IsSpace(r):
+		case r == '(' || r == ')':
+			tokens = append(tokens, queryToken{value: string(r)})
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, errors.New("unterminated quote in query")
+			}
+			tokens = append(tokens, queryToken{value: string(runes[i+1 : end]), quoted: true})
+			i = end
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '(' && runes[end] != ')' {
+				end++
+			}
+			tokens = append(tokens, queryToken{value: string(runes[i:end])})
+			i = end - 1
+		}
+	}
+	return tokens, nil
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.position >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.position], true
+}
+
+func (p *queryParser) parseOr() (tagExpression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token, ok := p.peek()
+		if !ok || !token.is("or") {
+			return left, nil
+		}
+		p.position++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpression{left, right}
+	}
+}
+
+func (p *queryParser) parseAnd() (tagExpression, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token, ok := p.peek()
+		if !ok || !token.is("and") {
+			return left, nil
+		}
+		p.position++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpression{left, right}
+	}
+}
+
+func (p *queryParser) parseNot() (tagExpression, error) {
+	token, ok := p.peek()
+	if ok && token.is("not") {
+		p.position++
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpression{operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (tagExpression, error) {
+	token, ok := p.peek()
+	if !ok {
+		return nil, errors.New("unexpected end of query")
+	}
+	p.position++
+	if token.quoted {
+		return tagTerm(token.value), nil
+	}
+	switch {
+	case token.value == "(":
+		expression, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.peek(); !ok || closing.quoted || closing.value != ")" {
+			return nil, errors.New("missing closing parenthesis in query")
+		}
+		p.position++
+		return expression, nil
+	case token.value == ")", token.is("and"), token.is("or"):
+		return nil, fmt.Errorf("unexpected %q in query", token.value)
+	}
+	return tagTerm(token.value), nil
+}
+
+func tagSet(tags []string) map[string]bool {
+	set := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		set[tag] = true
+	}
+	return set
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+type RetagCommand struct {
+	options       Configuration
+	flags         *flag.FlagSet
+	store         EntryStore
+	consoleWriter *os.File
+}
+
+// NewRetagCommand creates a new command runner for renaming a tag across all entries
+func NewRetagCommand(config Configuration, store EntryStore, consoleWriter *os.File) *RetagCommand {
+	retagCommand := RetagCommand{
+		options:       config,
+		flags:         flag.NewFlagSet("retag", flag.ExitOnError),
+		store:         store,
+		consoleWriter: consoleWriter,
+	}
+	return &retagCommand
+}
+
+// Run the retag command
+func (r *RetagCommand) Run(ctx context.Context, subcommandArgs []string) error {
+	if !r.flags.Parsed() {
+		if err := r.flags.Parse(subcommandArgs); err != nil {
+			return err
+		}
+	}
+	commandArgs := r.flags.Args()
+	if len(commandArgs) != 2 {
+		return errors.New("must provide the old and new tag")
+	}
+	oldTag, newTag := commandArgs[0], commandArgs[1]
+	if oldTag == newTag {
+		return errors.New("old and new tag must be different")
+	}
+	j, err := openJournal(r.options, r.store)
+	if err != nil {
+		return err
+	}
+	entries, err := j.entries()
+	if err != nil {
+		return err
+	}
+	changed := 0
+	for _, entry := range entries {
+		if !removeTags(entry, []string{oldTag}) {
+			continue
+		}
+		tags := dedupe(append(entry.Tags(), newTag))
+		sort.Strings(tags)
+		entry.SetTags(tags)
+		if err := j.write(entry); err != nil {
+			return err
+		}
+		fmt.Fprintln(r.consoleWriter, entry.Filepath)
+		changed++
+	}
+	fmt.Fprintf(r.consoleWriter, "%d of %d entries changed\n", changed, len(entries))
+	return nil
+}
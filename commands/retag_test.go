@@ -0,0 +1,40 @@
+package commands_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func TestRetag(t *testing.T) {
+	store := fixtureStore(t)
+	r, w, _ := os.Pipe()
+	cmd := commands.NewRetagCommand(commands.Configuration{}, store, w)
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 6, 0, 0, 0, 0, time.UTC))
+	if err := cmd.Run(ctx, []string{"db", "incident"}); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	output, _ := ioutil.ReadAll(r)
+	expectedOutput := "entries/2018-08-02.md\nentries/2018-08-03.md\nentries/2018-08-05.md\n3 of 5 entries changed\n"
+	if expectedOutput != string(output) {
+		t.Errorf("Expected %v, got %v", expectedOutput, string(output))
+	}
+	content := readEntry(t, store, "2018-08-03")
+	expectedContent := "---\ndate: Fri Aug 3 2018 00:00:00 +0000 UTC\ntags:\n- incident\n- resolved\n---\nRaised the pool size and the incident is resolved."
+	if expectedContent != content {
+		t.Errorf("Expected %v, got %v", expectedContent, content)
+	}
+}
+
+func TestRetagRequiresTwoTags(t *testing.T) {
+	cmd := commands.NewRetagCommand(commands.Configuration{}, commands.NewMemoryStore(), os.Stdout)
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 6, 0, 0, 0, 0, time.UTC))
+	if err := cmd.Run(ctx, []string{"db"}); err == nil {
+		t.Error("Expected an error with a single tag")
+	}
+}
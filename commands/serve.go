@@ -0,0 +1,382 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errCrossOrigin      = errors.New("cross-origin requests are not allowed")
+	errMethodNotAllowed = errors.New("method not allowed")
+	errInvalidHost      = errors.New("requests must be addressed to the listen address or localhost")
+)
+
+// serverPages are the pages only the server has.
+var serverPages = map[string]string{
+	"search": `{{define "content"}}<h1>Search</h1>
+<form action="{{searchPage}}">
+<input name="q" type="search" value="{{.Query}}" placeholder="Text" autofocus>
+<input name="where" value="{{.Where}}" placeholder="Tags, ie: incident and not resolved">
+<button type="submit">Search</button>
+</form>
+{{with .Error}}<p class="error">{{.}}</p>
+{{end}}<ul>
+{{range .Results}}<li><a href="{{entryPage .Entry.Name}}">{{.Entry.Name}}</a> {{.Entry.Summary}}{{range .Snippets}}<br><code>{{.}}</code>{{end}}</li>
+{{end}}</ul>
+{{end}}`,
+	"edit": `{{define "content"}}<h1>{{.Title}}</h1>
+{{with .Error}}<p class="error">{{.}}</p>
+{{end}}<form method="post">
+<textarea name="document" rows="24" cols="80">{{.Document}}</textarea>
+<p><button type="submit">Save</button> <a href="{{entryPage .Entry.Name}}">Cancel</a></p>
+</form>
+{{end}}`,
+}
+
+type ServeCommand struct {
+	options       Configuration
+	flags         *flag.FlagSet
+	store         EntryStore
+	consoleWriter *os.File
+	// address is where the server listens, set by the -addr flag.
+	address string
+}
+
+// journalServer is the web interface of a journal.
+type journalServer struct {
+	options   Configuration
+	store     EntryStore
+	journal   *journal
+	templates map[string]*template.Template
+	// now is the date of a request.
+	now func() time.Time
+	// mutex is held while an entry is read, changed and written, so
+	// concurrent requests do not lose each other's changes.
+	mutex sync.Mutex
+}
+
+// NewServeCommand creates a new command runner for serving the journal over HTTP
+func NewServeCommand(config Configuration, store EntryStore, consoleWriter *os.File) *ServeCommand {
+	serveCommand := ServeCommand{
+		options:       config,
+		flags:         flag.NewFlagSet("serve", flag.ExitOnError),
+		store:         store,
+		consoleWriter: consoleWriter,
+	}
+	return &serveCommand
+}
+
+// Run the serve command
+func (s *ServeCommand) Run(ctx context.Context, subcommandArgs []string) error {
+	s.flags.StringVar(&s.address, "addr", "localhost:8080", "Address to listen on.")
+	if !s.flags.Parsed() {
+		if err := s.flags.Parse(subcommandArgs); err != nil {
+			return err
+		}
+	}
+	handler, err := s.Handler(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.consoleWriter, "serving %s on http://%s\n", s.options.JournalPath, s.address)
+	return http.ListenAndServe(s.address, handler)
+}
+
+// Handler returns the web interface of the journal. Requests are dated from
+// the date of the context, as time passes. Only requests addressed to the
+// listen address or a loopback name are served, so pages of other sites can
+// not reach the journal by rebinding their name to it.
+func (s *ServeCommand) Handler(ctx context.Context) (http.Handler, error) {
+	j, err := openJournal(s.options, s.store)
+	if err != nil {
+		return nil, err
+	}
+	date := ctx.Value(CommandContextKey("date")).(time.Time)
+	started := time.Now()
+	server := &journalServer{
+		options: s.options,
+		store:   s.store,
+		journal: j,
+		now: func() time.Time {
+			return date.Add(time.Since(started))
+		},
+	}
+	links := siteLinks{
+		archive: "/",
+		tags:    "/tags",
+		search:  "/search",
+		today: func() string {
+			return entryURL(s.options.entryName(server.now()))
+		},
+		entry: entryURL,
+		tag: func(tag string) string {
+			return "/tags/" + url.PathEscape(tag)
+		},
+		edit: func(name string) string {
+			return entryURL(name) + "/edit"
+		},
+	}
+	if server.templates, err = htmlTemplates(links, serverPages); err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", server.archive)
+	mux.HandleFunc("/tags", server.tags)
+	mux.HandleFunc("/tags/", server.tag)
+	mux.HandleFunc("/entries/", server.entry)
+	mux.HandleFunc("/search", server.search)
+	mux.HandleFunc("/bin/", server.attachment)
+	mux.Handle("/api/", http.StripPrefix("/api", server.apiHandler()))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowedHost(r.Host, s.address) {
+			http.Error(w, errInvalidHost.Error(), http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}), nil
+}
+
+// allowedHost reports whether the Host of a request is the listen address or
+// a loopback name.
+func allowedHost(host string, address string) bool {
+	if host == "" {
+		return false
+	}
+	if strings.EqualFold(host, address) {
+		return true
+	}
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if listen, _, err := net.SplitHostPort(address); err == nil && listen != "" && strings.EqualFold(host, listen) {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback()
+	}
+	return strings.EqualFold(host, "localhost")
+}
+
+func entryURL(name string) string {
+	return "/entries/" + url.PathEscape(name)
+}
+
+func (s *journalServer) render(w http.ResponseWriter, status int, kind string, page htmlPage) {
+	var output bytes.Buffer
+	if err := s.templates[kind].ExecuteTemplate(&output, "layout", page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(output.Bytes())
+}
+
+func (s *journalServer) index(w http.ResponseWriter) (*journalIndex, bool) {
+	index, err := tagMap(s.journal, dateRange{filenameFormat: s.options.filenameDateFormat()})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return index, true
+}
+
+func (s *journalServer) archive(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if index, ok := s.index(w); ok {
+		s.render(w, http.StatusOK, "archive", htmlPage{Title: "Archive", Groups: monthGroups(index)})
+	}
+}
+
+func (s *journalServer) tags(w http.ResponseWriter, r *http.Request) {
+	if index, ok := s.index(w); ok {
+		s.render(w, http.StatusOK, "tags", htmlPage{Title: "Tags", Groups: tagGroups(index)})
+	}
+}
+
+func (s *journalServer) tag(w http.ResponseWriter, r *http.Request) {
+	index, ok := s.index(w)
+	if !ok {
+		return
+	}
+	tag := strings.TrimPrefix(r.URL.Path, "/tags/")
+	for _, group := range tagGroups(index) {
+		if group.Heading == tag {
+			s.render(w, http.StatusOK, "tag", htmlPage{Title: tag, Groups: []htmlGroup{group}})
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func (s *journalServer) entry(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/entries/")
+	if strings.HasSuffix(name, "/edit") {
+		s.edit(w, r, strings.TrimSuffix(name, "/edit"))
+		return
+	}
+	entry, err := s.journal.read(name)
+	if os.IsNotExist(err) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	body := template.HTML("<p><em>This entry is encrypted.</em></p>\n")
+	if !entry.locked {
+		body = template.HTML(renderMarkdown(entry.Content, true, func(destination string, image bool) string {
+			if image && path.Dir(destination) == "bin" {
+				return "/" + destination
+			}
+			if !image && isWikiLink(destination) {
+				return entryURL(destination)
+			}
+			return destination
+		}))
+	}
+	s.render(w, http.StatusOK, "entry", htmlPage{
+		Title: name,
+		Body:  body,
+		Entry: newIndexEntry(entry, s.options.filenameDateFormat()),
+	})
+}
+
+// edit shows the document of an entry in a form, and saves it like open
+// does when the form is posted. A missing entry is created like open would.
+func (s *journalServer) edit(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method == http.MethodPost {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+	}
+	entry, err := s.journal.read(name)
+	if os.IsNotExist(err) {
+		entry, err = s.newEntry(r.Context(), name)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := s.journal.writable(entry); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	page := htmlPage{Title: "Edit " + name, Entry: indexEntry{Name: name}}
+	switch r.Method {
+	case http.MethodGet:
+		document, err := entry.MarshalFrontmatter()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		page.Document = string(document)
+		s.render(w, http.StatusOK, "edit", page)
+	case http.MethodPost:
+		if !sameOrigin(r) {
+			http.Error(w, errCrossOrigin.Error(), http.StatusForbidden)
+			return
+		}
+		page.Document = strings.Replace(r.FormValue("document"), "\r\n", "\n", -1)
+		if err := s.journal.update(entry, []byte(page.Document)); err != nil {
+			page.Error = err.Error()
+			s.render(w, http.StatusBadRequest, "edit", page)
+			return
+		}
+		http.Redirect(w, r, entryURL(name), http.StatusSeeOther)
+	default:
+		http.Error(w, errMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+	}
+}
+
+// search finds entries like find does: by tag expression and content.
+func (s *journalServer) search(w http.ResponseWriter, r *http.Request) {
+	page := htmlPage{Title: "Search", Query: r.FormValue("q"), Where: r.FormValue("where")}
+	results, err := s.find(page.Query, page.Where)
+	if err != nil {
+		page.Error = err.Error()
+	}
+	page.Results = results
+	s.render(w, http.StatusOK, "search", page)
+}
+
+func (s *journalServer) find(query string, where string) ([]htmlResult, error) {
+	entries, matches, err := s.queryEntries(query, where, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []htmlResult
+	for i, entry := range entries {
+		result := htmlResult{Entry: newIndexEntry(entry, s.options.filenameDateFormat())}
+		for _, match := range matches[i] {
+			result.Snippets = append(result.Snippets, match.snippet(false))
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// queryEntries finds entries like find does, by content, tag expression and
+// tags. Nothing is found without a query.
+func (s *journalServer) queryEntries(query string, where string, tags []string) ([]*entryHeader, [][]contentMatch, error) {
+	matcher, err := contentMatcher(query, "", true)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter := entryFilter{tags: tags, period: dateRange{filenameFormat: s.options.filenameDateFormat()}}
+	if where != "" {
+		if filter.expression, err = parseTagExpression(where); err != nil {
+			return nil, nil, err
+		}
+	}
+	if matcher == nil && !filter.isSet() {
+		return nil, nil, nil
+	}
+	entries, err := s.journal.entries()
+	if err != nil {
+		return nil, nil, err
+	}
+	found, matches := findEntries(entries, filter, matcher)
+	return found, matches, nil
+}
+
+func (s *journalServer) attachment(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/bin/")
+	data, err := s.store.Attachment(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Write(data)
+}
+
+// sameOrigin reports whether a request was sent by a page of the server, so
+// other sites can not post to it from the browser.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	return err == nil && parsed.Host == r.Host
+}
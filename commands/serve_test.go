@@ -0,0 +1,129 @@
+package commands_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func TestServe(t *testing.T) {
+	store := fixtureStore(t)
+	store.Write("2018-08-06", []byte("---\ntags:\n- db\n---\nFollow up on [the incident](2018-08-02).\n\n![](bin/pixel.png)\n"))
+	store.Attach("pixel.png", []byte("png"))
+	store.Write("Scripted", []byte("<script>alert(1)</script>\n\n[Click](javascript:alert(1))\n"))
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 9, 30, 0, 0, time.UTC))
+	handler, err := commands.NewServeCommand(commands.Configuration{}, store, os.Stdout).Handler(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	inputs := []struct {
+		name     string
+		path     string
+		status   int
+		contains []string
+	}{
+		{"archive", "/", http.StatusOK, []string{"<h2>August 2018</h2>", `<a href="/entries/2018-08-05">2018-08-05</a> Vacuumed the reporting database.`, `<a href="/entries/2018-08-07">Today</a>`}},
+		{"tags", "/tags", http.StatusOK, []string{`<a href="/tags/incident">incident</a> (3)`}},
+		{"tag", "/tags/db", http.StatusOK, []string{`<a href="/entries/2018-08-06">2018-08-06</a> Follow up on [the incident](2018-08-02).`}},
+		{"missing tag", "/tags/nothing", http.StatusNotFound, nil},
+		{"entry", "/entries/2018-08-06", http.StatusOK, []string{`<a href="/entries/2018-08-02">the incident</a>`, `<img src="/bin/pixel.png"`, `<a href="/entries/2018-08-06/edit">Edit</a>`}},
+		{"missing entry", "/entries/2017-01-01", http.StatusNotFound, nil},
+		{"search content", "/search?q=POOL", http.StatusOK, []string{`<a href="/entries/2018-08-03">2018-08-03</a>`, "<code>Raised the pool size and the incident is resolved.</code>"}},
+		{"search tags", "/search?where=" + url.QueryEscape("incident and not db"), http.StatusOK, []string{`<a href="/entries/2018-08-04">2018-08-04</a>`}},
+		{"search error", "/search?where=" + url.QueryEscape("incident and"), http.StatusOK, []string{`<p class="error">`}},
+		{"edit", "/entries/2018-08-06/edit", http.StatusOK, []string{"<textarea name=\"document\" rows=\"24\" cols=\"80\">---\ntags:\n- db\n---\nFollow up"}},
+		{"new entry", "/entries/2018-08-07/edit", http.StatusOK, []string{"date: Tue Aug 7 2018 09:30:00 &#43;0000 UTC"}},
+		{"image", "/bin/pixel.png", http.StatusOK, []string{"png"}},
+		{"raw html", "/entries/Scripted", http.StatusOK, []string{"Click"}},
+	}
+	excludes := map[string][]string{
+		"/entries/Scripted": {"<script>", "javascript:"},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			response, err := http.Get(server.URL + input.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+			body, _ := ioutil.ReadAll(response.Body)
+			if response.StatusCode != input.status {
+				t.Errorf("Expected status %d, got %d", input.status, response.StatusCode)
+			}
+			for _, expected := range input.contains {
+				if !strings.Contains(string(body), expected) {
+					t.Errorf("Expected %q in %s", expected, body)
+				}
+			}
+			for _, unexpected := range excludes[input.path] {
+				if strings.Contains(string(body), unexpected) {
+					t.Errorf("Expected no %q in %s", unexpected, body)
+				}
+			}
+		})
+	}
+
+	t.Run("save", func(t *testing.T) {
+		document := "---\ntags:\n- db\n- followup\n---\r\nChecked the pool.\r\n"
+		response, err := http.PostForm(server.URL+"/entries/2018-08-07/edit", url.Values{"document": {document}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.Request.URL.Path != "/entries/2018-08-07" {
+			t.Errorf("Expected a redirect to the entry, got %v", response.Request.URL)
+		}
+		if content := readEntry(t, store, "2018-08-07"); content != "---\ntags:\n- db\n- followup\n---\nChecked the pool.\n" {
+			t.Errorf("Expected the entry to be saved, got %q", content)
+		}
+	})
+
+	t.Run("invalid document", func(t *testing.T) {
+		response, err := http.PostForm(server.URL+"/entries/2018-08-07/edit", url.Values{"document": {"---\ndate: yesterday\n---\n"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected a bad request, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("cross origin", func(t *testing.T) {
+		request, _ := http.NewRequest(http.MethodPost, server.URL+"/entries/2018-08-07/edit", strings.NewReader("document=hacked"))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("Origin", "https://example.com")
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected a forbidden request, got %d", response.StatusCode)
+		}
+	})
+
+	t.Run("rebound host", func(t *testing.T) {
+		request, _ := http.NewRequest(http.MethodGet, server.URL+"/entries/2018-08-06", nil)
+		request.Host = "attacker.example.com"
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected a forbidden request, got %d", response.StatusCode)
+		}
+	})
+}
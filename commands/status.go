@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type StatusCommand struct {
+	options       Configuration
+	flags         *flag.FlagSet
+	store         EntryStore
+	runner        GitCommandRunner
+	consoleWriter *os.File
+}
+
+// NewStatusCommand creates a new command runner for showing what memorize will do
+func NewStatusCommand(config Configuration, store EntryStore, runner GitCommandRunner, consoleWriter *os.File) *StatusCommand {
+	statusCommand := StatusCommand{
+		options:       config,
+		flags:         flag.NewFlagSet("status", flag.ExitOnError),
+		store:         store,
+		runner:        runner,
+		consoleWriter: consoleWriter,
+	}
+	return &statusCommand
+}
+
+// Run the status command
+func (s *StatusCommand) Run(ctx context.Context, subcommandArgs []string) error {
+	fetch := s.flags.Bool("fetch", false, "Fetch the remote first, so ahead and behind are up to date.")
+	if !s.flags.Parsed() {
+		if err := s.flags.Parse(subcommandArgs); err != nil {
+			return err
+		}
+	}
+	if *fetch {
+		if err := s.runner.Fetch(s.options.JournalPath); err != nil {
+			return err
+		}
+	}
+	status, err := s.runner.Status(s.options.JournalPath)
+	if err != nil {
+		return err
+	}
+	j, err := openJournal(s.options, s.store)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(s.consoleWriter, branchSummary(status))
+	today := currentEntryName(ctx, s.options)
+	if _, err := j.read(today); os.IsNotExist(err) {
+		fmt.Fprintf(s.consoleWriter, "Today's entry %s has not been written\n", today)
+	} else if err != nil {
+		fmt.Fprintf(s.consoleWriter, "Today's entry %s can not be read: %v\n", today, err)
+	} else {
+		fmt.Fprintf(s.consoleWriter, "Today's entry %s exists\n", today)
+	}
+
+	changes := newJournalChanges(status.Files, false)
+	problems, err := frontmatterProblems(j)
+	if err != nil {
+		return err
+	}
+	for _, group := range []struct {
+		label string
+		items []string
+	}{
+		{"New entries", changes.added},
+		{"Modified entries", changes.modified},
+		{"Deleted entries", changes.deleted},
+		{"Images", changes.images},
+		{"Other files", changes.other},
+		{"Entries without frontmatter", problems},
+	} {
+		if len(group.items) == 0 {
+			continue
+		}
+		fmt.Fprintf(s.consoleWriter, "\n%s:\n  %s\n", group.label, strings.Join(group.items, "\n  "))
+	}
+	if changes.empty() {
+		fmt.Fprintln(s.consoleWriter, "\nNothing to memorize")
+	}
+	return nil
+}
+
+func branchSummary(status GitStatus) string {
+	branch := "Not on a branch"
+	if status.Branch != "" {
+		branch = "On branch " + status.Branch
+	}
+	if status.Upstream == "" {
+		return branch + ", with no upstream"
+	}
+	switch {
+	case status.Ahead > 0 && status.Behind > 0:
+		return fmt.Sprintf("%s, %d ahead and %d behind %s", branch, status.Ahead, status.Behind, status.Upstream)
+	case status.Ahead > 0:
+		return fmt.Sprintf("%s, %d ahead of %s", branch, status.Ahead, status.Upstream)
+	case status.Behind > 0:
+		return fmt.Sprintf("%s, %d behind %s", branch, status.Behind, status.Upstream)
+	}
+	return fmt.Sprintf("%s, up to date with %s", branch, status.Upstream)
+}
+
+// frontmatterProblems lists the entries without frontmatter, or whose
+// frontmatter can not be read.
+func frontmatterProblems(j *journal) ([]string, error) {
+	names, err := j.store.List()
+	if err != nil {
+		return nil, err
+	}
+	var problems []string
+	for _, name := range names {
+		entry, err := j.read(name)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s (%v)", name, err))
+		} else if len(entry.fields) == 0 {
+			problems = append(problems, name)
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,86 @@
+package commands_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func TestStatus(t *testing.T) {
+	changes := []commands.GitFileStatus{
+		{Path: "entries/2018-08-06.md", Code: "??"},
+		{Path: "entries/2018-08-02.md", Code: ".M"},
+		{Path: "entries/2018-07-01.md", Code: ".D"},
+		{Path: "bin/pixel.png", Code: "A."},
+	}
+	inputs := []struct {
+		name     string
+		args     []string
+		date     time.Time
+		status   commands.GitStatus
+		expected string
+		calls    string
+	}{
+		{
+			"changes",
+			[]string{},
+			time.Date(2018, time.August, 6, 0, 0, 0, 0, time.UTC),
+			commands.GitStatus{Branch: "master", Upstream: "origin/master", Ahead: 2, Behind: 1, Files: changes},
+			"On branch master, 2 ahead and 1 behind origin/master\nToday's entry 2018-08-06 exists\n\nNew entries:\n  2018-08-06\n\nModified entries:\n  2018-08-02\n\nDeleted entries:\n  2018-07-01\n\nImages:\n  bin/pixel.png\n\nEntries without frontmatter:\n  2018-08-06\n",
+			"status /some/path",
+		},
+		{
+			"clean",
+			[]string{"-fetch"},
+			time.Date(2018, time.August, 7, 0, 0, 0, 0, time.UTC),
+			commands.GitStatus{Branch: "master"},
+			"On branch master, with no upstream\nToday's entry 2018-08-07 has not been written\n\nEntries without frontmatter:\n  2018-08-06\n\nNothing to memorize\n",
+			"fetch /some/path\nstatus /some/path",
+		},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			store := fixtureStore(t)
+			store.Write("2018-08-06", []byte("Forgot the frontmatter.\n"))
+			runner := newFakeGitCommand()
+			runner.status = input.status
+			r, w, _ := os.Pipe()
+			ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), input.date)
+			cmd := commands.NewStatusCommand(commands.Configuration{JournalPath: "/some/path"}, store, runner, w)
+			if err := cmd.Run(ctx, input.args); err != nil {
+				t.Fatal(err)
+			}
+			w.Close()
+			output, _ := ioutil.ReadAll(r)
+			if input.expected != string(output) {
+				t.Errorf("Expected %q, got %q", input.expected, string(output))
+			}
+			if calls := runner.calls(); input.calls != calls {
+				t.Errorf("Expected calls %q, got %q", input.calls, calls)
+			}
+		})
+	}
+}
+
+func TestStatusUnreadableEntry(t *testing.T) {
+	store := fixtureStore(t)
+	store.Write("2018-08-08", []byte("---\ndate: tomorrow\n---\nWritten ahead.\n"))
+	runner := newFakeGitCommand()
+	runner.status = commands.GitStatus{Branch: "master"}
+	r, w, _ := os.Pipe()
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 8, 0, 0, 0, 0, time.UTC))
+	cmd := commands.NewStatusCommand(commands.Configuration{JournalPath: "/some/path"}, store, runner, w)
+	if err := cmd.Run(ctx, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	output, _ := ioutil.ReadAll(r)
+	if expected := "Today's entry 2018-08-08 can not be read: entries/2018-08-08.md: "; !strings.Contains(string(output), expected) {
+		t.Errorf("Expected %q in %q", expected, string(output))
+	}
+}
@@ -0,0 +1,263 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EntryStore persists the entries of a journal. Entries are identified by
+// name: the entry filename without the ".md" extension.
+type EntryStore interface {
+	// List returns the names of all entries, sorted.
+	List() ([]string, error)
+	// Read returns the document of an entry. Reading a missing entry returns
+	// an error for which os.IsNotExist is true.
+	Read(name string) ([]byte, error)
+	// Write creates or replaces the document of an entry.
+	Write(name string, document []byte) error
+	// Delete removes an entry.
+	Delete(name string) error
+	// Attach stores a binary file, such as an image, that entries link to as bin/<name>.
+	Attach(name string, data []byte) error
+	// Attachments returns the names of the stored binary files, sorted.
+	Attachments() ([]string, error)
+	// Attachment returns a binary file stored with Attach.
+	Attachment(name string) ([]byte, error)
+	// WritePage stores a generated page, such as the index, alongside the entries.
+	WritePage(name string, content []byte) error
+	// Pages returns the names of the pages alongside the entries, sorted.
+	Pages() ([]string, error)
+	// DeletePage removes a page stored with WritePage.
+	DeletePage(name string) error
+	// Path describes where an entry is stored, for display.
+	Path(name string) string
+}
+
+// localStore is implemented by stores that keep entries in local files the
+// editor can open in place.
+type localStore interface {
+	localPath(name string) string
+}
+
+// FileStore stores entries as markdown files in the entries directory of a
+// journal, which is usually a cloned Github wiki.
+type FileStore struct {
+	journalPath string
+}
+
+// NewFileStore creates a store for the journal at the path
+func NewFileStore(journalPath string) *FileStore {
+	return &FileStore{journalPath: journalPath}
+}
+
+func (f *FileStore) List() ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(f.journalPath, "entries"))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".md") {
+			names = append(names, strings.TrimSuffix(file.Name(), ".md"))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (f *FileStore) Read(name string) ([]byte, error) {
+	return ioutil.ReadFile(f.Path(name))
+}
+
+func (f *FileStore) Write(name string, document []byte) error {
+	if err := os.MkdirAll(filepath.Join(f.journalPath, "entries"), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(f.Path(name), document, 0644)
+}
+
+func (f *FileStore) Delete(name string) error {
+	return os.Remove(f.Path(name))
+}
+
+func (f *FileStore) Attach(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Join(f.journalPath, "bin"), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(f.journalPath, "bin", path.Base(name)), data, 0644)
+}
+
+func (f *FileStore) Attachments() ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(f.journalPath, "bin"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, file := range files {
+		if !file.IsDir() {
+			names = append(names, file.Name())
+		}
+	}
+	return names, nil
+}
+
+func (f *FileStore) Attachment(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(f.journalPath, "bin", path.Base(name)))
+}
+
+func (f *FileStore) WritePage(name string, content []byte) error {
+	return ioutil.WriteFile(filepath.Join(f.journalPath, path.Base(name)), content, 0644)
+}
+
+func (f *FileStore) Pages() ([]string, error) {
+	files, err := ioutil.ReadDir(f.journalPath)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".md") {
+			names = append(names, file.Name())
+		}
+	}
+	return names, nil
+}
+
+func (f *FileStore) DeletePage(name string) error {
+	return os.Remove(filepath.Join(f.journalPath, path.Base(name)))
+}
+
+func (f *FileStore) Path(name string) string {
+	return fmt.Sprintf("%s/entries/%s.md", f.journalPath, name)
+}
+
+func (f *FileStore) localPath(name string) string {
+	return f.Path(name)
+}
+
+// MemoryStore keeps entries in memory. It is safe for concurrent use.
+type MemoryStore struct {
+	mutex       sync.RWMutex
+	entries     map[string][]byte
+	attachments map[string][]byte
+	pages       map[string][]byte
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries:     make(map[string][]byte),
+		attachments: make(map[string][]byte),
+		pages:       make(map[string][]byte),
+	}
+}
+
+func (m *MemoryStore) List() ([]string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	names := make([]string, 0, len(m.entries))
+	for name := range m.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (m *MemoryStore) Read(name string) ([]byte, error) {
+	return m.get(m.entries, m.Path(name), name)
+}
+
+func (m *MemoryStore) Write(name string, document []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.entries[name] = append([]byte{}, document...)
+	return nil
+}
+
+func (m *MemoryStore) Delete(name string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.entries[name]; !ok {
+		return &os.PathError{Op: "remove", Path: m.Path(name), Err: os.ErrNotExist}
+	}
+	delete(m.entries, name)
+	return nil
+}
+
+func (m *MemoryStore) Attach(name string, data []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.attachments[path.Base(name)] = append([]byte{}, data...)
+	return nil
+}
+
+func (m *MemoryStore) Attachments() ([]string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	names := make([]string, 0, len(m.attachments))
+	for name := range m.attachments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (m *MemoryStore) Attachment(name string) ([]byte, error) {
+	return m.get(m.attachments, "bin/"+name, name)
+}
+
+func (m *MemoryStore) WritePage(name string, content []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.pages[path.Base(name)] = append([]byte{}, content...)
+	return nil
+}
+
+func (m *MemoryStore) Pages() ([]string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	names := make([]string, 0, len(m.pages))
+	for name := range m.pages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (m *MemoryStore) DeletePage(name string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.pages[path.Base(name)]; !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	delete(m.pages, path.Base(name))
+	return nil
+}
+
+// Page returns a page stored with WritePage.
+func (m *MemoryStore) Page(name string) ([]byte, error) {
+	return m.get(m.pages, name, name)
+}
+
+func (m *MemoryStore) Path(name string) string {
+	return fmt.Sprintf("entries/%s.md", name)
+}
+
+func (m *MemoryStore) get(values map[string][]byte, displayPath, name string) ([]byte, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	value, ok := values[name]
+	if !ok {
+		return nil, &os.PathError{Op: "read", Path: displayPath, Err: os.ErrNotExist}
+	}
+	return append([]byte{}, value...), nil
+}
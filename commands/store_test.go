@@ -0,0 +1,77 @@
+package commands_test
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func TestStores(t *testing.T) {
+	path, err := ioutil.TempDir("", "jrnl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+	stores := []struct {
+		name  string
+		store commands.EntryStore
+	}{
+		{"file", commands.NewFileStore(path)},
+		{"memory", commands.NewMemoryStore()},
+	}
+	for _, input := range stores {
+		t.Run(input.name, func(t *testing.T) {
+			store := input.store
+			for _, name := range []string{"2018-08-02", "2018-08-01"} {
+				if err := store.Write(name, []byte("content of "+name)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			names, err := store.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := []string{"2018-08-01", "2018-08-02"}; !reflect.DeepEqual(names, expected) {
+				t.Errorf("Expected %v, got %v", expected, names)
+			}
+			if content := readEntry(t, store, "2018-08-01"); content != "content of 2018-08-01" {
+				t.Errorf("Expected content of 2018-08-01, got %v", content)
+			}
+			if err := store.Delete("2018-08-01"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Read("2018-08-01"); !os.IsNotExist(err) {
+				t.Errorf("Expected a deleted entry to not exist, got %v", err)
+			}
+			if err := store.Attach("../pixel.png", []byte("png")); err != nil {
+				t.Fatal(err)
+			}
+			if attachments, err := store.Attachments(); err != nil || !reflect.DeepEqual(attachments, []string{"pixel.png"}) {
+				t.Errorf("Expected the pixel.png attachment, got %v %v", attachments, err)
+			}
+			if content, err := store.Attachment("pixel.png"); err != nil || string(content) != "png" {
+				t.Errorf("Expected the attachment content, got %q %v", content, err)
+			}
+			for _, page := range []string{"Index.md", "Tag-old.md"} {
+				if err := store.WritePage(page, []byte("index")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := store.DeletePage("Tag-old.md"); err != nil {
+				t.Fatal(err)
+			}
+			if pages, err := store.Pages(); err != nil || !reflect.DeepEqual(pages, []string{"Index.md"}) {
+				t.Errorf("Expected the Index.md page, got %v %v", pages, err)
+			}
+		})
+	}
+	if content, err := ioutil.ReadFile(path + "/bin/pixel.png"); err != nil || string(content) != "png" {
+		t.Errorf("Expected the attachment in the bin directory, got %v", err)
+	}
+	if content, err := ioutil.ReadFile(path + "/Index.md"); err != nil || string(content) != "index" {
+		t.Errorf("Expected the page in the journal directory, got %v", err)
+	}
+}
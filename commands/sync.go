@@ -3,41 +3,150 @@ package commands
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
 )
 
+const mergeMarker = "\n---\n\n*Merged by jrnl sync: the other copy of this entry continues below.*\n\n"
+
 type SyncCommand struct {
-	options Configuration
-	runner  GitCommandRunner
+	options       Configuration
+	flags         *flag.FlagSet
+	store         EntryStore
+	runner        GitCommandRunner
+	consoleWriter *os.File
 }
 
-type GitCommandRunner interface {
-	Pull(path string) error
+// NewSyncCommand creates a new command runner for sync command
+func NewSyncCommand(config Configuration, store EntryStore, runner GitCommandRunner, consoleWriter *os.File) *SyncCommand {
+	syncCommand := SyncCommand{
+		options:       config,
+		flags:         flag.NewFlagSet("sync", flag.ExitOnError),
+		store:         store,
+		runner:        runner,
+		consoleWriter: consoleWriter,
+	}
+	return &syncCommand
 }
 
-type GitCommandRunnerImpl struct{}
+// Run the sync command
+func (s *SyncCommand) Run(ctx context.Context, subcommandArgs []string) error {
+	if !s.flags.Parsed() {
+		if err := s.flags.Parse(subcommandArgs); err != nil {
+			return err
+		}
+	}
+	err := s.runner.Pull(s.options.JournalPath)
+	if !gitErrorIs(err, ErrConflict) {
+		return err
+	}
+	status, err := s.runner.Status(s.options.JournalPath)
+	if err != nil {
+		return err
+	}
+	j, err := openJournal(s.options, s.store)
+	if err != nil {
+		return err
+	}
+	var merged, unresolved []string
+	for _, file := range status.Unmerged() {
+		if err := s.mergeEntry(j, file); err != nil {
+			unresolved = append(unresolved, file.Path)
+			fmt.Fprintf(s.consoleWriter, "needs attention: %s (%v)\n", file.Path, err)
+			continue
+		}
+		merged = append(merged, file.Path)
+		fmt.Fprintf(s.consoleWriter, "merged: %s\n", file.Path)
+	}
+	if len(merged) > 0 {
+		if err := s.runner.Add(s.options.JournalPath, merged...); err != nil {
+			return err
+		}
+	}
+	if len(unresolved) > 0 {
+		return fmt.Errorf("%d conflicting files need to be resolved and committed with git", len(unresolved))
+	}
+	message := "Merged journal\n\nMerged: " + strings.Join(merged, ", ")
+	return s.runner.Commit(s.options.JournalPath, message)
+}
 
-func (g GitCommandRunnerImpl) Pull(path string) error {
-	params := []string{
-		"-C",
-		path,
-		"pull",
+// mergeEntry resolves a conflicted entry by combining both copies of it.
+func (s *SyncCommand) mergeEntry(j *journal, file GitFileStatus) error {
+	if path.Dir(file.Path) != "entries" || path.Ext(file.Path) != ".md" {
+		return errors.New("not an entry")
+	}
+	if file.Code != "UU" && file.Code != "AA" {
+		return errors.New("deleted on one side")
 	}
-	if code := gitCommand(params...); code != 0 {
-		return errors.New("failed to sync journal")
+	name := strings.TrimSuffix(path.Base(file.Path), ".md")
+	var copies []*entryHeader
+	for _, stage := range []string{StageOurs, StageTheirs} {
+		content, err := s.runner.Show(s.options.JournalPath, stage, file.Path)
+		if err != nil {
+			return err
+		}
+		entry, err := j.parse(name, content)
+		if err != nil {
+			return err
+		}
+		if entry.locked {
+			return errMissingKey
+		}
+		copies = append(copies, entry)
 	}
-	return nil
+	return j.write(mergeEntries(copies[0], copies[1]))
 }
 
-// NewSyncCommand creates a new command runner for sync command
-func NewSyncCommand(config Configuration, runner GitCommandRunner) *SyncCommand {
-	syncCommand := SyncCommand{
-		options: config,
-		runner:  runner,
+// mergeEntries combines two copies of an entry. The frontmatter keeps our
+// fields, adding the union of the tags, the earliest date and any field only
+// they have. The part of their content that differs from ours is appended
+// after a marker.
+func mergeEntries(ours *entryHeader, theirs *entryHeader) *entryHeader {
+	merged := *ours
+	merged.fields = append(merged.fields[:0:0], ours.fields...)
+	for _, field := range theirs.fields {
+		if _, ok := merged.Field(fmt.Sprint(field.Key)); !ok {
+			merged.fields = append(merged.fields, field)
+		}
 	}
-	return &syncCommand
+	tags := dedupe(append(append([]string{}, ours.Tags()...), theirs.Tags()...))
+	sort.Strings(tags)
+	merged.SetTags(tags)
+	if date := theirs.Date(); !date.IsZero() && (ours.Date().IsZero() || date.Before(ours.Date())) {
+		merged.SetDate(date)
+	}
+	merged.encrypted = ours.encrypted || theirs.encrypted
+	merged.Content = mergeContent(ours.Content, theirs.Content)
+	return &merged
 }
 
-// Run the sync command
-func (s *SyncCommand) Run(ctx context.Context, subcommandArgs []string) error {
-	return s.runner.Pull(s.options.JournalPath)
+func mergeContent(ours string, theirs string) string {
+	if ours == theirs {
+		return ours
+	}
+	if !strings.HasSuffix(ours, "\n") {
+		ours += "\n"
+	}
+	if !strings.HasSuffix(theirs, "\n") {
+		theirs += "\n"
+	}
+	ourLines := strings.SplitAfter(ours, "\n")
+	theirLines := strings.SplitAfter(theirs, "\n")
+	common := 0
+	for common < len(ourLines) && common < len(theirLines) && ourLines[common] == theirLines[common] {
+		common++
+	}
+	theirRest := strings.Join(theirLines[common:], "")
+	if strings.TrimSpace(theirRest) == "" {
+		return ours
+	}
+	ours = strings.TrimRight(ours, "\n")
+	if strings.TrimSpace(strings.Join(ourLines[common:], "")) == "" {
+		return ours + "\n" + theirRest
+	}
+	return ours + "\n" + mergeMarker + theirRest
 }
@@ -2,6 +2,9 @@ package commands_test
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -9,22 +12,87 @@ import (
 )
 
 type fakeGitCommand struct {
+	// called has the arguments of the last call of each operation, and order
+	// every call in the order it was made.
 	called map[string][]string
+	order  *[]string
+	errors map[string]error
+	status commands.GitStatus
+	// files are returned by Show, keyed by revision and file, ie: ":2:entries/x.md".
+	files map[string]string
+	// log is returned by Log, keyed by file.
+	log map[string][]commands.GitCommit
+}
+
+func newFakeGitCommand() fakeGitCommand {
+	return fakeGitCommand{
+		called: make(map[string][]string),
+		order:  new([]string),
+		errors: make(map[string]error),
+		files:  make(map[string]string),
+		log:    make(map[string][]commands.GitCommit),
+	}
 }
 
 func (f fakeGitCommand) Pull(path string) error {
-	f.called["pull"] = []string{path}
-	return nil
+	f.record("pull", []string{path})
+	return f.errors["pull"]
 }
 
-func TestSyncRun(t *testing.T) {
-	runner := fakeGitCommand{
-		called: make(map[string][]string),
+func (f fakeGitCommand) Fetch(path string) error {
+	f.record("fetch", []string{path})
+	return f.errors["fetch"]
+}
+
+func (f fakeGitCommand) Add(path string, files ...string) error {
+	f.record("add", append([]string{path}, files...))
+	return f.errors["add"]
+}
+
+func (f fakeGitCommand) Commit(path string, message string) error {
+	f.record("commit", []string{path, message})
+	return f.errors["commit"]
+}
+
+func (f fakeGitCommand) Push(path string, remote string, branch string) error {
+	f.record("push", []string{path, remote, branch})
+	return f.errors["push"]
+}
+
+func (f fakeGitCommand) Status(path string) (commands.GitStatus, error) {
+	f.record("status", []string{path})
+	return f.status, f.errors["status"]
+}
+
+func (f fakeGitCommand) Show(path string, revision string, file string) ([]byte, error) {
+	content, ok := f.files[revision+":"+file]
+	if !ok {
+		return nil, &commands.GitError{Args: []string{"show", revision + ":" + file}}
 	}
+	return []byte(content), nil
+}
+
+func (f fakeGitCommand) Log(path string, file string) ([]commands.GitCommit, error) {
+	f.record("log", []string{path, file})
+	return f.log[file], f.errors["log"]
+}
+
+func (f fakeGitCommand) record(operation string, args []string) {
+	f.called[operation] = args
+	*f.order = append(*f.order, operation+" "+strings.Join(args, " "))
+}
+
+// calls lists the operations in the order they were called.
+func (f fakeGitCommand) calls() string {
+	return strings.Join(*f.order, "\n")
+}
+
+func TestSyncRun(t *testing.T) {
+	runner := newFakeGitCommand()
 	config := commands.Configuration{
 		JournalPath: "/some/path",
 	}
-	cmd := commands.NewSyncCommand(config, runner)
+	cmd := commands.NewSyncCommand(config, commands.NewMemoryStore(), runner, os.Stdout)
 	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.July, 28, 0, 0, 0, 0, time.UTC))
 	if err := cmd.Run(ctx, []string{}); err != nil {
 		t.Error(err)
@@ -38,3 +106,80 @@ func TestSyncRun(t *testing.T) {
 		t.Errorf("Expected %v, got %v.", expectedPullPath, runner.called["pull"][0])
 	}
 }
+
+func TestSyncMergesConflicts(t *testing.T) {
+	ours := "---\ndate: Tue Aug 7 2018 09:00:00 +0000 UTC\ntags:\n- deploy\nmood: 7\n---\n- 09:00 Standup\n- 10:00 Deployed v1.2\n"
+	theirs := "---\ndate: Tue Aug 7 2018 08:00:00 +0000 UTC\ntags:\n- incident\n- deploy\nlocation: home\n---\n- 09:00 Standup\n- 11:00 Rolled back\n"
+	merged := "---\ndate: Tue Aug 7 2018 08:00:00 +0000 UTC\ntags:\n- deploy\n- incident\nmood: 7\nlocation: home\n---\n- 09:00 Standup\n- 10:00 Deployed v1.2\n\n---\n\n*Merged by jrnl sync: the other copy of this entry continues below.*\n\n- 11:00 Rolled back\n"
+	appended := "---\ntags:\n- db\n---\nVacuumed the reporting database.\nAnd reindexed.\n"
+	inputs := []struct {
+		name         string
+		files        []commands.GitFileStatus
+		expected     string
+		output       string
+		expectsError bool
+	}{
+		{
+			"entries",
+			[]commands.GitFileStatus{{Path: "entries/2018-08-07.md", Code: "UU"}, {Path: "entries/2018-08-05.md", Code: "AA"}},
+			"pull /some/path\nstatus /some/path\nadd /some/path entries/2018-08-07.md entries/2018-08-05.md\ncommit /some/path Merged journal\n\nMerged: entries/2018-08-07.md, entries/2018-08-05.md",
+			"merged: entries/2018-08-07.md\nmerged: entries/2018-08-05.md\n",
+			false,
+		},
+		{
+			"needs attention",
+			[]commands.GitFileStatus{{Path: "entries/2018-08-07.md", Code: "UU"}, {Path: "Index.md", Code: "UU"}, {Path: "entries/2018-08-01.md", Code: "UD"}},
+			"pull /some/path\nstatus /some/path\nadd /some/path entries/2018-08-07.md",
+			"merged: entries/2018-08-07.md\nneeds attention: Index.md (not an entry)\nneeds attention: entries/2018-08-01.md (deleted on one side)\n",
+			true,
+		},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			store := fixtureStore(t)
+			runner := newFakeGitCommand()
+			runner.errors["pull"] = &commands.GitError{Reason: commands.ErrConflict}
+			runner.status = commands.GitStatus{Branch: "master", Files: append(input.files, commands.GitFileStatus{Path: "entries/2018-08-02.md", Code: "M."})}
+			runner.files[":2:entries/2018-08-07.md"] = ours
+			runner.files[":3:entries/2018-08-07.md"] = theirs
+			runner.files[":2:entries/2018-08-05.md"] = readEntry(t, store, "2018-08-05")
+			runner.files[":3:entries/2018-08-05.md"] = appended
+			r, w, _ := os.Pipe()
+			cmd := commands.NewSyncCommand(commands.Configuration{JournalPath: "/some/path"}, store, runner, w)
+			ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 0, 0, 0, 0, time.UTC))
+			err := cmd.Run(ctx, []string{})
+			if err != nil && !input.expectsError {
+				t.Fatal(err)
+			} else if err == nil && input.expectsError {
+				t.Error("Expected input to produce an error")
+			}
+			w.Close()
+			output, _ := ioutil.ReadAll(r)
+			if input.output != string(output) {
+				t.Errorf("Expected %q, got %q", input.output, string(output))
+			}
+			if calls := runner.calls(); calls != input.expected {
+				t.Errorf("Expected %v, got %v", input.expected, calls)
+			}
+			if content := readEntry(t, store, "2018-08-07"); content != merged {
+				t.Errorf("Expected %q, got %q", merged, content)
+			}
+		})
+	}
+	t.Run("appended", func(t *testing.T) {
+		store := fixtureStore(t)
+		runner := newFakeGitCommand()
+		runner.errors["pull"] = &commands.GitError{Reason: commands.ErrConflict}
+		runner.status = commands.GitStatus{Files: []commands.GitFileStatus{{Path: "entries/2018-08-05.md", Code: "UU"}}}
+		runner.files[":2:entries/2018-08-05.md"] = readEntry(t, store, "2018-08-05")
+		runner.files[":3:entries/2018-08-05.md"] = appended
+		cmd := commands.NewSyncCommand(commands.Configuration{}, store, runner, os.Stdout)
+		ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 0, 0, 0, 0, time.UTC))
+		if err := cmd.Run(ctx, []string{}); err != nil {
+			t.Fatal(err)
+		}
+		if content := readEntry(t, store, "2018-08-05"); content != appended {
+			t.Errorf("Expected %q, got %q", appended, content)
+		}
+	})
+}
@@ -4,89 +4,133 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
-	"sync"
+	"strings"
 	"time"
 )
 
 type TagCommand struct {
 	options Configuration
 	flags   *flag.FlagSet
+	store   EntryStore
+}
+
+// entrySelectors are the flags shared by commands that operate on specific entries.
+type entrySelectors struct {
+	files    arrayFlags
+	subjects arrayFlags
+	dates    arrayFlags
 }
 
 // NewTagCommand creates a new command runner for tagging entries
-func NewTagCommand(config Configuration) *TagCommand {
+func NewTagCommand(config Configuration, store EntryStore) *TagCommand {
 	tagCommand := TagCommand{
 		options: config,
 		flags:   flag.NewFlagSet("tag", flag.ExitOnError),
+		store:   store,
 	}
 	return &tagCommand
 }
 
 // Run the tag command
 func (t *TagCommand) Run(ctx context.Context, subcommandArgs []string) error {
-	var files arrayFlags
-	var subjects arrayFlags
 	var tags arrayFlags
-	var dates arrayFlags
-	t.flags.Var(&files, "f", "File path of document to tag")
-	t.flags.Var(&subjects, "s", "Subject(s) entries to tag")
-	t.flags.Var(&dates, "d", "Specify the date(s) of entry.")
+	selectors := newEntrySelectors(t.flags, "tag")
 	t.flags.Var(&tags, "t", "Tag or tags to append to specified files, subjects, or dates")
 	if !t.flags.Parsed() {
 		if err := t.flags.Parse(subcommandArgs); err != nil {
 			return err
 		}
 	}
-	var fileEntries []string
-	for _, file := range files {
-		fileEntries = append(fileEntries, file)
+	names, err := selectors.names(t.options)
+	if err != nil {
+		return err
 	}
-	for _, subject := range subjects {
-		fileEntries = append(fileEntries, fmt.Sprintf("%s/entries/%s.md", t.options.JournalPath, subject))
+	j, err := openJournal(t.options, t.store)
+	if err != nil {
+		return err
 	}
-	for _, date := range dates {
-		parsedDate, err := time.Parse("2006-01-02", date)
+	var entries []*entryHeader
+	if len(names) == 0 {
+		entry, err := j.read(currentEntryName(ctx, t.options))
+		if os.IsNotExist(err) {
+			entry, err = j.newEntry(currentEntryName(ctx, t.options)), nil
+		}
 		if err != nil {
 			return err
 		}
-		fileEntries = append(fileEntries, fmt.Sprintf("%s/entries/%s.md", t.options.JournalPath, parsedDate.String()))
-	}
-	if len(fileEntries) == 0 {
-		toCreate := fmt.Sprintf("%s/entries/%s.md", t.options.JournalPath, ctx.Value(CommandContextKey("date")).(time.Time).Format("2006-01-02"))
-		os.OpenFile(toCreate, os.O_RDONLY|os.O_CREATE, 0644)
-		fileEntries = append(fileEntries, toCreate)
+		entries = append(entries, entry)
+	} else if entries, err = j.readAll(names); err != nil {
+		return err
 	}
-	var wg sync.WaitGroup
-	results := make(chan frontmatterResult, len(fileEntries))
-	for _, file := range fileEntries {
-		wg.Add(1)
-		go func(filePath string) {
-			defer wg.Done()
-			readFrontmatter(filePath, results)
-		}(file)
-	}
-	wg.Wait()
-	close(results)
 	// @todo Make this async for performance after certain len()
-	for result := range results {
-		if result.err != nil {
-			return result.err
+	for _, entry := range entries {
+		entryTags := dedupe(append(entry.Tags(), tags...))
+		sort.Strings(entryTags)
+		entry.SetTags(entryTags)
+		if err := j.write(entry); err != nil {
+			return err
 		}
-		result.header.Tags = dedupe(append(result.header.Tags, tags...))
-		sort.Strings(result.header.Tags)
-		output, err := result.header.MarshalFrontmatter()
+	}
+	return nil
+}
+
+func newEntrySelectors(flags *flag.FlagSet, verb string) *entrySelectors {
+	selectors := entrySelectors{}
+	flags.Var(&selectors.files, "f", fmt.Sprintf("File path of document to %s", verb))
+	flags.Var(&selectors.subjects, "s", fmt.Sprintf("Subject(s) entries to %s", verb))
+	flags.Var(&selectors.dates, "d", "Specify the date(s) of entry.")
+	return &selectors
+}
+
+// names resolves the selected files, subjects and dates to entry names.
+func (e *entrySelectors) names(config Configuration) ([]string, error) {
+	var names []string
+	for _, file := range e.files {
+		name, err := entryFileName(config, file)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		err = ioutil.WriteFile(result.header.Filepath, output, 0644)
+		names = append(names, name)
+	}
+	for _, subject := range e.subjects {
+		names = append(names, subject)
+	}
+	for _, date := range e.dates {
+		parsedDate, err := time.Parse(entryDateFormat, date)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		names = append(names, config.entryName(parsedDate))
 	}
-	return nil
+	return names, nil
+}
+
+// entryFileName returns the name of the entry stored at a file path, which
+// is relative to the current directory or to the journal. Only files in the
+// entries directory of the journal are entries.
+func entryFileName(config Configuration, file string) (string, error) {
+	entries, err := filepath.Abs(filepath.Join(config.JournalPath, "entries"))
+	if err != nil {
+		return "", err
+	}
+	candidates := []string{file}
+	if !filepath.IsAbs(file) {
+		candidates = append(candidates, filepath.Join(config.JournalPath, file))
+	}
+	for _, candidate := range candidates {
+		path, err := filepath.Abs(candidate)
+		if err == nil && filepath.Dir(path) == entries && filepath.Ext(path) == ".md" {
+			return strings.TrimSuffix(filepath.Base(path), ".md"), nil
+		}
+	}
+	return "", fmt.Errorf("%s is not an entry in %s", file, entries)
+}
+
+func currentEntryName(ctx context.Context, config Configuration) string {
+	return config.entryName(ctx.Value(CommandContextKey("date")).(time.Time))
 }
 
 func dedupe(subject []string) []string {
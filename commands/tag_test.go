@@ -0,0 +1,102 @@
+package commands_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func TestTagPreservesFrontmatter(t *testing.T) {
+	store := fixtureStore(t)
+	inputs := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			"unknown fields",
+			"---\ntitle: Retro\nmood: 7\ndate: Wed Aug 1 2018 00:00:00 +0000 UTC\ntags:\n- foo\nlocation:\n  city: Nashville\n  remote: true\n---\n# Retro\n\nBody text.\n",
+			"---\ntitle: Retro\nmood: 7\ndate: Wed Aug 1 2018 00:00:00 +0000 UTC\ntags:\n- bar\n- foo\nlocation:\n  city: Nashville\n  remote: true\n---\n# Retro\n\nBody text.\n",
+		},
+		{
+			"no tags",
+			"---\ndate: Wed Aug 1 2018 00:00:00 +0000 UTC\ncustom: value\n---\nBody text.",
+			"---\ndate: Wed Aug 1 2018 00:00:00 +0000 UTC\ncustom: value\ntags:\n- bar\n---\nBody text.",
+		},
+		{
+			"no date",
+			"---\ntags:\n- foo\n---\nBody text.",
+			"---\ntags:\n- bar\n- foo\n---\nBody text.",
+		},
+		{
+			"no final newline",
+			"---\ntags: [foo]\n---",
+			"---\ntags:\n- bar\n- foo\n---\n",
+		},
+		{
+			"crlf",
+			"---\r\ntags:\r\n- foo\r\n---\r\nBody text.\r\n",
+			"---\ntags:\n- bar\n- foo\n---\nBody text.\n",
+		},
+		{
+			"no frontmatter",
+			"Body text.",
+			"---\ntags:\n- bar\n---\nBody text.",
+		},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			if err := store.Write("preserve", []byte(input.content)); err != nil {
+				t.Fatal(err)
+			}
+			cmd := commands.NewTagCommand(commands.Configuration{}, store)
+			ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 1, 0, 0, 0, 0, time.UTC))
+			if err := cmd.Run(ctx, []string{"-s", "preserve", "-t", "bar"}); err != nil {
+				t.Fatal(err)
+			}
+			content := readEntry(t, store, "preserve")
+			if input.expected != content {
+				t.Errorf("Expected %v, got %v", input.expected, content)
+			}
+		})
+	}
+}
+
+func TestTagFiles(t *testing.T) {
+	config := commands.Configuration{JournalPath: "/some/journal"}
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 1, 0, 0, 0, 0, time.UTC))
+	inputs := []struct {
+		name         string
+		file         string
+		expectsError bool
+	}{
+		{"absolute", filepath.Join(config.JournalPath, "entries", "2018-08-02.md"), false},
+		{"relative to the journal", "entries/2018-08-03.md", false},
+		{"outside entries", filepath.Join(config.JournalPath, "Index.md"), true},
+		{"other directory", "/tmp/notes/2018-08-04.md", true},
+		{"not markdown", filepath.Join(config.JournalPath, "entries", "2018-08-05.txt"), true},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			store := fixtureStore(t)
+			err := commands.NewTagCommand(config, store).Run(ctx, []string{"-f", input.file, "-t", "tagged"})
+			if input.expectsError {
+				if err == nil {
+					t.Error("Expected a file outside the entries directory to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			name := strings.TrimSuffix(filepath.Base(input.file), ".md")
+			if content := readEntry(t, store, name); !strings.Contains(content, "- tagged\n") {
+				t.Errorf("Expected %s to be tagged, got %q", name, content)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var openTodoPattern = regexp.MustCompile(`^\s*[-*+] \[ \] (.+)$`)
+
+// TemplateRules selects the template of a new entry by weekday or subject.
+type TemplateRules struct {
+	Weekdays map[string]string `yaml:"weekdays"`
+	Subjects map[string]string `yaml:"subjects"`
+}
+
+func (t TemplateRules) rules() [][2]string {
+	var rules [][2]string
+	for _, group := range []struct {
+		name  string
+		rules map[string]string
+	}{{"weekdays", t.Weekdays}, {"subjects", t.Subjects}} {
+		keys := make([]string, 0, len(group.rules))
+		for key := range group.rules {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			rules = append(rules, [2]string{group.name + "." + key, group.rules[key]})
+		}
+	}
+	return rules
+}
+
+// templateData is available to entry templates.
+type templateData struct {
+	Date          time.Time
+	Weekday       string
+	Year          int
+	Week          int
+	Subject       string
+	Tags          []string
+	PreviousEntry string
+	PreviousTodos []string
+}
+
+// templateName returns the configured template for a new entry, preferring a
+// subject rule, then a weekday rule, then the default template.
+func (c Configuration) templateName(date time.Time, subject string) string {
+	if subject != "" {
+		if name, ok := c.Templates.Subjects[subject]; ok {
+			return name
+		}
+	} else if name, ok := c.Templates.Weekdays[strings.ToLower(date.Weekday().String())]; ok {
+		return name
+	}
+	return c.DefaultTemplate
+}
+
+func (c Configuration) templatePath(name string) string {
+	if filepath.Ext(name) == "" {
+		name += ".md"
+	}
+	return filepath.Join(c.JournalPath, "templates", name)
+}
+
+// generateEntry creates a new entry: frontmatter with the date and default
+// tags, followed by the rendered template if one applies. The entry is named
+// after the subject, or the date when there is no subject.
+func generateEntry(ctx context.Context, config Configuration, j *journal, subject string, templateName string) (*entryHeader, error) {
+	date := ctx.Value(CommandContextKey("date")).(time.Time)
+	name := subject
+	if name == "" {
+		name = config.entryName(date)
+	}
+	entry := j.newEntry(name)
+	entry.SetDate(date)
+	entry.SetTags(config.DefaultTags)
+	if templateName == "" {
+		templateName = config.templateName(date, subject)
+	}
+	if templateName != "" {
+		content, err := renderTemplate(config, j, templateName, date, subject)
+		if err != nil {
+			return nil, err
+		}
+		entry.Content = content
+	}
+	return entry, nil
+}
+
+func renderTemplate(config Configuration, j *journal, name string, date time.Time, subject string) (string, error) {
+	source, err := ioutil.ReadFile(config.templatePath(name))
+	if err != nil {
+		return "", fmt.Errorf("unable to read template %q: %v", name, err)
+	}
+	entryTemplate, err := template.New(name).Parse(string(source))
+	if err != nil {
+		return "", err
+	}
+	year, week := date.ISOWeek()
+	data := templateData{
+		Date:    date,
+		Weekday: date.Weekday().String(),
+		Year:    year,
+		Week:    week,
+		Subject: subject,
+		Tags:    config.DefaultTags,
+	}
+	if previous, ok := previousEntryName(config, j, date); ok {
+		data.PreviousEntry = previous
+		data.PreviousTodos, err = openTodos(j, previous)
+		if err != nil {
+			return "", err
+		}
+	}
+	var output bytes.Buffer
+	if err := entryTemplate.Execute(&output, data); err != nil {
+		return "", err
+	}
+	return output.String(), nil
+}
+
+// previousEntryName finds the most recent dated entry before the date.
+func previousEntryName(config Configuration, j *journal, date time.Time) (string, bool) {
+	names, err := j.store.List()
+	if err != nil {
+		return "", false
+	}
+	current := config.entryName(date)
+	var previous string
+	var previousDate time.Time
+	for _, name := range names {
+		entryDate, err := time.ParseInLocation(config.filenameDateFormat(), name, date.Location())
+		if err != nil || name == current || !entryDate.Before(date) {
+			continue
+		}
+		if previous == "" || entryDate.After(previousDate) {
+			previous, previousDate = name, entryDate
+		}
+	}
+	return previous, previous != ""
+}
+
+func openTodos(j *journal, name string) ([]string, error) {
+	entry, err := j.read(name)
+	if err != nil {
+		return nil, err
+	}
+	var todos []string
+	for _, line := range strings.Split(entry.Content, "\n") {
+		if match := openTodoPattern.FindStringSubmatch(line); match != nil {
+			todos = append(todos, strings.TrimSpace(match[1]))
+		}
+	}
+	return todos, nil
+}
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+type UntagCommand struct {
+	options       Configuration
+	flags         *flag.FlagSet
+	store         EntryStore
+	consoleWriter *os.File
+}
+
+// NewUntagCommand creates a new command runner for removing tags from entries
+func NewUntagCommand(config Configuration, store EntryStore, consoleWriter *os.File) *UntagCommand {
+	untagCommand := UntagCommand{
+		options:       config,
+		flags:         flag.NewFlagSet("untag", flag.ExitOnError),
+		store:         store,
+		consoleWriter: consoleWriter,
+	}
+	return &untagCommand
+}
+
+// Run the untag command
+func (u *UntagCommand) Run(ctx context.Context, subcommandArgs []string) error {
+	var tags arrayFlags
+	selectors := newEntrySelectors(u.flags, "untag")
+	u.flags.Var(&tags, "t", "Tag or tags to remove from specified files, subjects, or dates")
+	if !u.flags.Parsed() {
+		if err := u.flags.Parse(subcommandArgs); err != nil {
+			return err
+		}
+	}
+	if len(tags) == 0 {
+		return errors.New("must provide a tag to remove")
+	}
+	names, err := selectors.names(u.options)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		names = append(names, currentEntryName(ctx, u.options))
+	}
+	j, err := openJournal(u.options, u.store)
+	if err != nil {
+		return err
+	}
+	entries, err := j.readAll(names)
+	if err != nil {
+		return err
+	}
+	changed := 0
+	for _, entry := range entries {
+		if !removeTags(entry, tags) {
+			continue
+		}
+		if err := j.write(entry); err != nil {
+			return err
+		}
+		fmt.Fprintln(u.consoleWriter, entry.Filepath)
+		changed++
+	}
+	fmt.Fprintf(u.consoleWriter, "%d of %d entries changed\n", changed, len(entries))
+	return nil
+}
+
+// removeTags removes the given tags from an entry and reports whether any were present.
+func removeTags(entry *entryHeader, tags []string) bool {
+	remove := tagSet(tags)
+	current := entry.Tags()
+	kept := make([]string, 0, len(current))
+	for _, tag := range current {
+		if !remove[tag] {
+			kept = append(kept, tag)
+		}
+	}
+	if len(kept) == len(current) {
+		return false
+	}
+	entry.SetTags(kept)
+	return true
+}
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+type WriteCommand struct {
+	options Configuration
+	flags   *flag.FlagSet
+	store   EntryStore
+	input   *os.File
+}
+
+// NewWriteCommand creates a new command runner for appending to an entry without an editor
+func NewWriteCommand(config Configuration, store EntryStore, input *os.File) *WriteCommand {
+	writeCommand := WriteCommand{
+		options: config,
+		flags:   flag.NewFlagSet("write", flag.ExitOnError),
+		store:   store,
+		input:   input,
+	}
+	return &writeCommand
+}
+
+// Run the write command
+func (w *WriteCommand) Run(ctx context.Context, subcommandArgs []string) error {
+	var tags arrayFlags
+	subjectFlag := w.flags.String("s", "", "Set the subject (this will not use a journal date.")
+	paragraph := w.flags.Bool("p", false, "Append a paragraph instead of a bullet.")
+	w.flags.Var(&tags, "t", "Tag or tags to append to the entry.")
+	if !w.flags.Parsed() {
+		if err := w.flags.Parse(subcommandArgs); err != nil {
+			return err
+		}
+	}
+	text, err := w.text()
+	if err != nil {
+		return err
+	}
+	date := ctx.Value(CommandContextKey("date")).(time.Time)
+	name := *subjectFlag
+	if name == "" {
+		name = w.options.entryName(date)
+	}
+	j, err := openJournal(w.options, w.store)
+	if err != nil {
+		return err
+	}
+	entry, err := j.read(name)
+	if os.IsNotExist(err) {
+		entry, err = generateEntry(ctx, w.options, j, *subjectFlag, "")
+	}
+	if err != nil {
+		return err
+	}
+	entry.Content = appendText(entry.Content, text, date, *paragraph)
+	if len(tags) > 0 {
+		entryTags := dedupe(append(entry.Tags(), tags...))
+		sort.Strings(entryTags)
+		entry.SetTags(entryTags)
+	}
+	return j.write(entry)
+}
+
+// text returns the text to append from the arguments, or from the input when
+// the only argument is "-".
+func (w *WriteCommand) text() (string, error) {
+	commandArgs := w.flags.Args()
+	var text string
+	if len(commandArgs) == 1 && commandArgs[0] == "-" {
+		input, err := ioutil.ReadAll(w.input)
+		if err != nil {
+			return "", err
+		}
+		text = string(input)
+	} else {
+		text = strings.Join(commandArgs, " ")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.New("must provide text to write")
+	}
+	return text, nil
+}
+
+func appendText(content string, text string, date time.Time, paragraph bool) string {
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	timestamp := date.Format("15:04")
+	if paragraph {
+		if content != "" {
+			content += "\n"
+		}
+		return fmt.Sprintf("%s**%s** %s\n", content, timestamp, text)
+	}
+	return fmt.Sprintf("%s- %s %s\n", content, timestamp, strings.Replace(text, "\n", "\n  ", -1))
+}
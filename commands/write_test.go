@@ -0,0 +1,74 @@
+package commands_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cjsaylor/jrnl/commands"
+)
+
+func TestWrite(t *testing.T) {
+	store := fixtureStore(t)
+	inputs := []struct {
+		name     string
+		args     []string
+		stdin    string
+		entry    string
+		expected string
+	}{
+		{
+			"new entry",
+			[]string{"-t", "deploy", "Deployed", "v1.2"},
+			"",
+			"2018-08-07",
+			"---\ndate: Tue Aug 7 2018 14:30:00 +0000 UTC\ntags:\n- deploy\n---\n- 14:30 Deployed v1.2\n",
+		},
+		{
+			"existing entry",
+			[]string{"-s", "2018-08-01", "-t", "bar", "-t", "baz", "Another thing"},
+			"",
+			"2018-08-01",
+			"---\ndate: Wed Aug 1 2018 00:00:00 +0000 UTC\ntags:\n- bar\n- baz\n- foo\n---\nSome Content\n- 14:30 Another thing\n",
+		},
+		{
+			"stdin paragraph",
+			[]string{"-p", "-s", "2018-08-04", "-"},
+			"Rolled forward.\nAll green.\n",
+			"2018-08-04",
+			"---\ndate: Sat Aug 4 2018 00:00:00 +0000 UTC\ntags:\n- incident\n---\nDeploy rolled back after failed health checks.\n\n**14:30** Rolled forward.\nAll green.\n",
+		},
+		{
+			"stdin bullet",
+			[]string{"-s", "2018-08-05", "-"},
+			"First line\nsecond line",
+			"2018-08-05",
+			"---\ntags:\n- db\n---\nVacuumed the reporting database.\n- 14:30 First line\n  second line\n",
+		},
+	}
+	for _, input := range inputs {
+		t.Run(input.name, func(t *testing.T) {
+			r, w, _ := os.Pipe()
+			w.WriteString(input.stdin)
+			w.Close()
+			cmd := commands.NewWriteCommand(commands.Configuration{}, store, r)
+			ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 14, 30, 0, 0, time.UTC))
+			if err := cmd.Run(ctx, input.args); err != nil {
+				t.Fatal(err)
+			}
+			content := readEntry(t, store, input.entry)
+			if input.expected != content {
+				t.Errorf("Expected %v, got %v", input.expected, content)
+			}
+		})
+	}
+}
+
+func TestWriteRequiresText(t *testing.T) {
+	cmd := commands.NewWriteCommand(commands.Configuration{}, commands.NewMemoryStore(), os.Stdin)
+	ctx := context.WithValue(context.Background(), commands.CommandContextKey("date"), time.Date(2018, time.August, 7, 14, 30, 0, 0, time.UTC))
+	if err := cmd.Run(ctx, []string{}); err == nil {
+		t.Error("Expected an error without text")
+	}
+}
@@ -6,6 +6,9 @@ require (
 	github.com/caarlos0/env v0.0.0-20170430215205-7110c4e2f569
 	github.com/ericaro/frontmatter v0.0.0-20141225210444-9fedef9406e4
 	github.com/kr/pretty v0.1.0 // indirect
+	github.com/russross/blackfriday/v2 v2.1.0
 	github.com/stretchr/testify v1.4.0 // indirect
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
+	gopkg.in/yaml.v2 v2.2.2
 )